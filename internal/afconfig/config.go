@@ -2,9 +2,13 @@
 package afconfig
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -16,16 +20,190 @@ type Config struct {
 	// used to override the builtin filepaths for custom installation locations
 	CustomBrowserPath      string
 	CustomSSOBrowserPath   string
-	Keyring                *KeyringConfig `toml:",omitempty"`
+	Keyring                *KeyringConfig     `toml:",omitempty"`
+	Agent                  *AgentConfig       `toml:",omitempty"`
+	Browser                *BrowserConfig     `toml:",omitempty"`
+	Credentials            *CredentialsConfig `toml:",omitempty"`
 	Ordering               string
 	ExportCredentialSuffix string
 }
 
 type KeyringConfig struct {
-	Backend                 *string `toml:",omitempty"`
-	KeychainName            *string `toml:",omitempty"`
-	FileDir                 *string `toml:",omitempty"`
+	// secret-service, keychain, wincred, file or pass, the native keyring of the platform if empty
+	Backend      *string `toml:",omitempty"`
+	KeychainName *string `toml:",omitempty"`
+	FileDir      *string `toml:",omitempty"`
+	// file with the passphrase of the file backend, used when AWS_FUZZY_KEYRING_PASSPHRASE is not set
+	FilePassphraseFile      *string `toml:",omitempty"`
 	LibSecretCollectionName *string `toml:",omitempty"`
+	// prefix of the entries of the pass backend, "aws-fuzzy" if empty
+	PassPrefix *string `toml:",omitempty"`
+	// how long a keyring operation can take, e.g. "10s", raise it if the keyring is slow to unlock
+	Timeout *string `toml:",omitempty"`
+}
+
+type AgentConfig struct {
+	// duration of the IAM Identity Center portal session configured by the AWS admin, e.g. "8h"
+	SessionDuration *string `toml:",omitempty"`
+	// how long before the portal session expires to start warning, e.g. "30m"
+	WarnBefore *string `toml:",omitempty"`
+	// how many frecent profiles the agent keeps credentials refreshed for
+	Profiles *int `toml:",omitempty"`
+}
+
+type CredentialsConfig struct {
+	// how long before cached role credentials expire to fetch new ones, e.g. "10m"
+	RefreshBuffer *string `toml:",omitempty"`
+}
+
+type BrowserConfig struct {
+	// name of the Firefox container of each profile, fields: .Profile, .AccountID, .Role and .Session
+	ContainerTemplate *string `toml:",omitempty"`
+	// name of the Chrome, Edge, Brave or Chromium profile of each profile, same fields as ContainerTemplate
+	ChromeProfileTemplate *string `toml:",omitempty"`
+	// Firefox container color and icon used when the profile does not set one, "auto" derives them from the account ID
+	Color *string `toml:",omitempty"`
+	Icon  *string `toml:",omitempty"`
+}
+
+// allowed by the granted-containers Firefox extension
+// https://github.com/onebytegone/granted-containers/blob/main/src/opener/parser.ts#L14
+var (
+	FirefoxColors = []string{"blue", "turquoise", "green", "yellow", "orange", "red", "pink", "purple"}
+	FirefoxIcons  = []string{"fingerprint", "briefcase", "dollar", "cart", "circle", "gift", "vacation", "food", "fruit", "pet", "tree", "chill"}
+)
+
+const (
+	DefaultContainerTemplate     = "{{.Profile}}"
+	DefaultChromeProfileTemplate = "{{.AccountID}}"
+	// derive the Firefox container color or icon from the account ID
+	BrowserAuto = "auto"
+)
+
+const (
+	// default IAM Identity Center portal session duration
+	DefaultSessionDuration = 8 * time.Hour
+	DefaultWarnBefore      = 30 * time.Minute
+	DefaultAgentProfiles   = 5
+)
+
+// DefaultCredentialsRefreshBuffer is how long before cached role credentials expire to fetch new ones
+const DefaultCredentialsRefreshBuffer = 5 * time.Minute
+
+// AgentSession returns the portal session duration and how long before it expires to warn the user
+func (c Config) AgentSession() (time.Duration, time.Duration) {
+	duration, warn := DefaultSessionDuration, DefaultWarnBefore
+
+	if c.Agent == nil {
+		return duration, warn
+	}
+
+	if c.Agent.SessionDuration != nil {
+		d, err := time.ParseDuration(*c.Agent.SessionDuration)
+		if err == nil {
+			duration = d
+		}
+	}
+
+	if c.Agent.WarnBefore != nil {
+		d, err := time.ParseDuration(*c.Agent.WarnBefore)
+		if err == nil {
+			warn = d
+		}
+	}
+
+	return duration, warn
+}
+
+// CredentialsRefreshBuffer returns how long before cached role credentials expire to fetch new ones
+func (c Config) CredentialsRefreshBuffer() time.Duration {
+	if c.Credentials == nil || c.Credentials.RefreshBuffer == nil {
+		return DefaultCredentialsRefreshBuffer
+	}
+
+	d, err := time.ParseDuration(*c.Credentials.RefreshBuffer)
+	if err != nil || d < 0 {
+		return DefaultCredentialsRefreshBuffer
+	}
+
+	return d
+}
+
+// AgentProfiles returns how many frecent profiles the agent keeps warm
+func (c Config) AgentProfiles() int {
+	if c.Agent == nil || c.Agent.Profiles == nil {
+		return DefaultAgentProfiles
+	}
+
+	return *c.Agent.Profiles
+}
+
+// BrowserTemplates returns the Firefox container and Chrome profile name templates
+func (c Config) BrowserTemplates() (string, string) {
+	container, chrome := DefaultContainerTemplate, DefaultChromeProfileTemplate
+
+	if c.Browser == nil {
+		return container, chrome
+	}
+
+	if c.Browser.ContainerTemplate != nil && *c.Browser.ContainerTemplate != "" {
+		container = *c.Browser.ContainerTemplate
+	}
+
+	if c.Browser.ChromeProfileTemplate != nil && *c.Browser.ChromeProfileTemplate != "" {
+		chrome = *c.Browser.ChromeProfileTemplate
+	}
+
+	return container, chrome
+}
+
+// BrowserContainerStyle returns the default Firefox container color and icon
+func (c Config) BrowserContainerStyle() (string, string) {
+	color, icon := BrowserAuto, BrowserAuto
+
+	if c.Browser == nil {
+		return color, icon
+	}
+
+	if c.Browser.Color != nil && *c.Browser.Color != "" {
+		color = *c.Browser.Color
+	}
+
+	if c.Browser.Icon != nil && *c.Browser.Icon != "" {
+		icon = *c.Browser.Icon
+	}
+
+	return color, icon
+}
+
+// ValidateFirefoxColor returns an error if color is not auto or one of FirefoxColors
+func ValidateFirefoxColor(color string) error {
+	if color == BrowserAuto || slices.Contains(FirefoxColors, color) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid Firefox container color '%s', allowed values: %s, %s", color, BrowserAuto, strings.Join(FirefoxColors, ", "))
+}
+
+// ValidateFirefoxIcon returns an error if icon is not auto or one of FirefoxIcons
+func ValidateFirefoxIcon(icon string) error {
+	if icon == BrowserAuto || slices.Contains(FirefoxIcons, icon) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid Firefox container icon '%s', allowed values: %s, %s", icon, BrowserAuto, strings.Join(FirefoxIcons, ", "))
+}
+
+// ValidateBrowser checks the browser settings
+func (c Config) ValidateBrowser() error {
+	color, icon := c.BrowserContainerStyle()
+
+	err := ValidateFirefoxColor(color)
+	if err != nil {
+		return err
+	}
+
+	return ValidateFirefoxIcon(icon)
 }
 
 func NewLoadedConfig() (Config, error) {
@@ -48,6 +226,20 @@ func NewDefaultConfig() Config {
 	return cfg
 }
 
+// KeyringFileDir returns the directory of the file keyring backend
+func (c Config) KeyringFileDir() (string, error) {
+	if c.Keyring != nil && c.Keyring.FileDir != nil && *c.Keyring.FileDir != "" {
+		return *c.Keyring.FileDir, nil
+	}
+
+	configFolder, err := c.ConfigFolder()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(configFolder, "keyring"), nil
+}
+
 // checks and or creates the config folder on startup
 func (c Config) SetupConfigFolder() error {
 	configFolder, err := c.ConfigFolder()
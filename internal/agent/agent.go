@@ -0,0 +1,367 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/AndreZiviani/aws-fuzzy/internal/agent/client"
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// role credentials expiring sooner than this are refreshed
+const refreshBuffer = 10 * time.Minute
+
+type state struct {
+	mu sync.Mutex
+	// role credentials of the profiles being kept warm
+	creds map[string]aws.Credentials
+	// profiles requested through the socket are kept warm too
+	requested map[string]struct{}
+	// valid IAM Identity Center tokens by token key, updated on every refresh
+	tokens map[string]*securestorage.SSOToken
+	// portal session expiry already warned about, by token key
+	warned map[string]time.Time
+
+	sessionDuration time.Duration
+	warnBefore      time.Duration
+}
+
+func New(profiles int, interval time.Duration, verbose bool) *Agent {
+	agent := Agent{
+		Profiles: profiles,
+		Interval: interval,
+		Verbose:  verbose,
+	}
+
+	return &agent
+}
+
+func (a *Agent) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "agent")
+	span.Finish()
+
+	if a.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	cfg, err := afconfig.NewLoadedConfig()
+	if err != nil {
+		return err
+	}
+
+	if a.Profiles == 0 {
+		a.Profiles = cfg.AgentProfiles()
+	}
+
+	st := &state{
+		creds:     make(map[string]aws.Credentials),
+		requested: make(map[string]struct{}),
+		tokens:    make(map[string]*securestorage.SSOToken),
+		warned:    make(map[string]time.Time),
+	}
+	st.sessionDuration, st.warnBefore = cfg.AgentSession()
+
+	err = cfg.SetupConfigFolder()
+	if err != nil {
+		return err
+	}
+
+	socket, err := client.SocketPath()
+	if err != nil {
+		return err
+	}
+
+	listener, err := listen(socket)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           a.handler(st),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(a.Interval)
+		defer ticker.Stop()
+
+		for {
+			a.refresh(ctx, st)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	clio.Infof("aws-fuzzy agent listening on %s, keeping credentials of the %d most used profiles refreshed", socket, a.Profiles)
+
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// listen creates the agent socket, only the current user can connect to it
+func listen(socket string) (net.Listener, error) {
+	if _, err := os.Stat(socket); err == nil {
+		conn, err := net.DialTimeout("unix", socket, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("an aws-fuzzy agent is already listening on %s", socket)
+		}
+
+		// stale socket left by an agent that did not exit cleanly
+		_ = os.Remove(socket)
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Chmod(socket, 0600)
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// refresh keeps the IAM Identity Center tokens and the role credentials of the warm profiles valid
+func (a *Agent) refresh(ctx context.Context, st *state) {
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		clio.Errorf("failed to load profiles: %s", err)
+		return
+	}
+
+	a.refreshTokens(ctx, st, profiles)
+
+	_, names := profiles.GetFrecentProfiles()
+	if len(names) > a.Profiles {
+		names = names[:a.Profiles]
+	}
+
+	st.mu.Lock()
+	for name := range st.requested {
+		names = append(names, name)
+	}
+	st.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		st.mu.Lock()
+		creds, ok := st.creds[name]
+		st.mu.Unlock()
+
+		if ok && !expiresSoon(creds) {
+			continue
+		}
+
+		_, err := a.fetch(ctx, st, profiles, name)
+		if err != nil {
+			clio.Debugf("not refreshing credentials for profile %s: %s", name, err)
+		}
+	}
+}
+
+// refreshTokens refreshes the IAM Identity Center token of every sso profile and warns before the portal session expires
+func (a *Agent) refreshTokens(ctx context.Context, st *state, profiles *awsprofile.Profiles) {
+	keys := make(map[string]struct{})
+	for _, name := range profiles.ProfileNames {
+		profile, err := profiles.LoadInitialisedProfile(ctx, name)
+		if err != nil || profile.ProfileType != awsprofile.ProfileTypeSSO {
+			continue
+		}
+
+		key := profile.SSOTokenKey()
+		if key != "" {
+			keys[key] = struct{}{}
+		}
+	}
+
+	tokenStore := securestorage.NewSecureSSOTokenStorage()
+	tokens := make(map[string]*securestorage.SSOToken)
+
+	for key := range keys {
+		// refreshes the access token using the refresh token when it is about to expire
+		token := tokenStore.GetValidSSOToken(ctx, key)
+		if token == nil {
+			continue
+		}
+
+		tokens[key] = token
+		a.warnSessionExpiry(st, key, token)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.tokens = tokens
+}
+
+func (a *Agent) warnSessionExpiry(st *state, key string, token *securestorage.SSOToken) {
+	if token.IssuedAt.IsZero() {
+		// tokens issued before the agent existed do not know when the session started
+		return
+	}
+
+	expiresAt := token.IssuedAt.Add(st.sessionDuration)
+	if time.Until(expiresAt) > st.warnBefore {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.warned[key].Equal(expiresAt) {
+		return
+	}
+	st.warned[key] = expiresAt
+
+	clio.Warnf("IAM Identity Center portal session for %s expires at %s, run 'aws-fuzzy sso login' to start a new session", key, expiresAt.Local().Format(time.Kitchen))
+}
+
+// fetch refreshes the role credentials of a profile, only profiles that can be refreshed without user interaction are supported
+func (a *Agent) fetch(ctx context.Context, st *state, profiles *awsprofile.Profiles, name string) (*aws.Credentials, error) {
+	profile, err := profiles.LoadInitialisedProfile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.ProfileType != awsprofile.ProfileTypeSSO {
+		return nil, fmt.Errorf("only IAM Identity Center profiles are supported")
+	}
+
+	if profile.AWSConfig.MFASerial != "" {
+		return nil, fmt.Errorf("profile requires MFA")
+	}
+	for _, parent := range profile.Parents {
+		if parent.AWSConfig.MFASerial != "" {
+			return nil, fmt.Errorf("parent profile %s requires MFA", parent.Name)
+		}
+	}
+
+	st.mu.Lock()
+	_, ok := st.tokens[profile.SSOTokenKey()]
+	st.mu.Unlock()
+
+	if !ok {
+		// avoid opening the browser from the background
+		return nil, fmt.Errorf("no valid IAM Identity Center token, login is required")
+	}
+
+	login := sso.Login{Profile: name}
+
+	creds, err := login.GetCredentialsValidFor(ctx, refreshBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	clio.Debugf("refreshed credentials for profile %s, they will expire at %s", name, creds.Expires.Local().Format(time.RFC1123))
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.creds[name] = *creds
+
+	return creds, nil
+}
+
+func (a *Agent) handler(st *state) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /credentials", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("profile")
+
+		profiles, err := awsprofile.LoadProfiles()
+		if err != nil || !profiles.HasProfile(name) {
+			http.NotFound(w, r)
+			return
+		}
+
+		st.mu.Lock()
+		creds, ok := st.creds[name]
+		st.mu.Unlock()
+
+		if !ok || expiresSoon(creds) {
+			c, err := a.fetch(r.Context(), st, profiles, name)
+			if err != nil {
+				clio.Debugf("not serving credentials for profile %s: %s", name, err)
+				http.NotFound(w, r)
+				return
+			}
+			creds = *c
+
+			st.mu.Lock()
+			st.requested[name] = struct{}{}
+			st.mu.Unlock()
+		}
+
+		res := client.CredentialsResponse{Credentials: creds}
+
+		profile, err := profiles.LoadInitialisedProfile(r.Context(), name)
+		if err == nil {
+			st.mu.Lock()
+			token, ok := st.tokens[profile.SSOTokenKey()]
+			st.mu.Unlock()
+
+			if ok && !token.IssuedAt.IsZero() {
+				res.SessionExpiresAt = token.IssuedAt.Add(st.sessionDuration)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+
+	return mux
+}
+
+func expiresSoon(creds aws.Credentials) bool {
+	return creds.CanExpire && time.Now().Add(refreshBuffer).After(creds.Expires)
+}
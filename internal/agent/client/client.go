@@ -0,0 +1,100 @@
+// package client talks to a running aws-fuzzy agent, it is kept separate from the agent
+// so commands can consult the agent without importing the credential refresh logic
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const socketName = "agent.sock"
+
+var ErrAgentNotRunning = errors.New("aws-fuzzy agent is not running")
+
+// ErrNoCredentials is returned when the agent does not have credentials for the profile,
+// e.g. profiles that require interactive authentication
+var ErrNoCredentials = errors.New("aws-fuzzy agent has no credentials for this profile")
+
+type CredentialsResponse struct {
+	Credentials aws.Credentials `json:"credentials"`
+	// when the IAM Identity Center portal session used to get the credentials expires, if known
+	SessionExpiresAt time.Time `json:"sessionExpiresAt,omitempty"`
+}
+
+// SocketPath returns the path of the unix socket the agent listens on
+func SocketPath() (string, error) {
+	cfg := afconfig.NewDefaultConfig()
+
+	folder, err := cfg.ConfigFolder()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(folder, socketName), nil
+}
+
+// NewHttpClient returns a http client that sends every request to the agent socket
+func NewHttpClient(socket string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// GetCredentials asks the agent for credentials of profile
+func GetCredentials(ctx context.Context, profile string) (*CredentialsResponse, error) {
+	socket, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(socket); err != nil {
+		return nil, ErrAgentNotRunning
+	}
+
+	// the agent may need to fetch credentials from AWS if they are not warm yet
+	client := NewHttpClient(socket, 30*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://agent/credentials?profile="+url.QueryEscape(profile), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotRunning, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNoCredentials
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from aws-fuzzy agent: %s", res.Status)
+	}
+
+	var out CredentialsResponse
+	err = json.NewDecoder(res.Body).Decode(&out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
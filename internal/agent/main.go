@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+type Agent struct {
+	Profiles int
+	Interval time.Duration
+	Verbose  bool
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:  "agent",
+		Usage: "Keep IAM Identity Center tokens and role credentials refreshed in the background",
+		Description: "The agent listens on a unix socket that is consulted by 'sso credential-process' and 'eks get-token' before\n" +
+			"fetching credentials themselves. It also warns before the IAM Identity Center portal session expires.",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "profiles", Usage: "How many frecent profiles to keep credentials refreshed for (default: 5)"},
+			&cli.DurationFlag{Name: "interval", Aliases: []string{"i"}, Usage: "How often to check for credentials that need to be refreshed", Value: time.Minute},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+		},
+		Action: func(c *cli.Context) error {
+			agent := New(c.Int("profiles"),
+				c.Duration("interval"),
+				c.Bool("verbose"),
+			)
+
+			return agent.Execute(c.Context)
+		},
+	}
+
+	return &command
+}
@@ -49,10 +49,10 @@ func (cpa *CredentialProcessAssumer) AssumeTerminal(ctx context.Context, c *Prof
 				}
 				if p.AWSConfig.MFASerial != "" {
 					aro.SerialNumber = &p.AWSConfig.MFASerial
-					aro.TokenProvider = MfaTokenProvider
+					aro.TokenProvider = MfaTokenProviderFor(ctx, p, p.AWSConfig.MFASerial)
 				} else if c.AWSConfig.MFASerial != "" {
 					aro.SerialNumber = &c.AWSConfig.MFASerial
-					aro.TokenProvider = MfaTokenProvider
+					aro.TokenProvider = MfaTokenProviderFor(ctx, c, c.AWSConfig.MFASerial)
 				}
 				aro.Duration = configOpts.Duration
 			})
@@ -73,7 +73,7 @@ func (cpa *CredentialProcessAssumer) AssumeTerminal(ctx context.Context, c *Prof
 			}
 			if c.AWSConfig.MFASerial != "" {
 				aro.SerialNumber = &c.AWSConfig.MFASerial
-				aro.TokenProvider = MfaTokenProvider
+				aro.TokenProvider = MfaTokenProviderFor(ctx, c, c.AWSConfig.MFASerial)
 			}
 			aro.Duration = configOpts.Duration
 		})
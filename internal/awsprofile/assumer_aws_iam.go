@@ -20,7 +20,13 @@ type AwsIamAssumer struct {
 func (aia *AwsIamAssumer) AssumeTerminal(ctx context.Context, c *Profile, configOpts ConfigOpts) (aws.Credentials, error) {
 	if c.HasSecureStorageIAMCredentials {
 		secureIAMCredentialStorage := securestorage.NewSecureIAMCredentialStorage()
-		return secureIAMCredentialStorage.GetCredentials(c.Name)
+		return secureIAMCredentialStorage.GetCredentials(ctx, c.Name)
+	}
+
+	// If the mfa_serial is defined on the root profile, we need to set it in this config so that the aws SDK knows to prompt for MFA token
+	mfaSerial := c.AWSConfig.MFASerial
+	if len(c.Parents) > 0 && c.Parents[0].AWSConfig.MFASerial != "" {
+		mfaSerial = c.Parents[0].AWSConfig.MFASerial
 	}
 
 	//using ~/.aws/credentials file for creds
@@ -29,15 +35,11 @@ func (aia *AwsIamAssumer) AssumeTerminal(ctx context.Context, c *Profile, config
 		config.WithSharedConfigProfile(c.Name),
 		config.WithAssumeRoleCredentialOptions(func(aro *stscreds.AssumeRoleOptions) {
 			// set the token provider up
-			aro.TokenProvider = MfaTokenProvider
+			aro.TokenProvider = MfaTokenProviderFor(ctx, c, mfaSerial)
 			aro.Duration = configOpts.Duration
 
-			// If the mfa_serial is defined on the root profile, we need to set it in this config so that the aws SDK knows to prompt for MFA token
-			if len(c.Parents) > 0 {
-				if c.Parents[0].AWSConfig.MFASerial != "" {
-					aro.SerialNumber = aws.String(c.Parents[0].AWSConfig.MFASerial)
-
-				}
+			if mfaSerial != "" {
+				aro.SerialNumber = aws.String(mfaSerial)
 			}
 			if c.AWSConfig.RoleSessionName != "" {
 				aro.RoleSessionName = c.AWSConfig.RoleSessionName
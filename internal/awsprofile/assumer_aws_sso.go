@@ -43,6 +43,20 @@ func (asa *AwsSsoAssumer) ProfileMatchesType(rawProfile *ini.Section, parsedProf
 	return parsedProfile.SSOAccountID != ""
 }
 
+// SSOTokenKey returns the key used to cache the IAM Identity Center token of this profile,
+// the sso-session name if the profile uses one or else the start url of the root profile
+func (c *Profile) SSOTokenKey() string {
+	if c.AWSConfig.SSOSession != nil {
+		return c.AWSConfig.SSOSession.Name
+	}
+
+	if len(c.Parents) > 0 {
+		return c.Parents[0].AWSConfig.SSOStartURL
+	}
+
+	return c.AWSConfig.SSOStartURL
+}
+
 func (c *Profile) SSOLogin(ctx context.Context, configOpts ConfigOpts) (aws.Credentials, error) {
 
 	rootProfile := c
@@ -75,7 +89,7 @@ func (c *Profile) SSOLogin(ctx context.Context, configOpts ConfigOpts) (aws.Cred
 			return aws.Credentials{}, err
 		}
 
-		secureSSOTokenStorage.StoreSSOToken(ssoTokenKey, *newSSOToken)
+		secureSSOTokenStorage.StoreSSOToken(ctx, ssoTokenKey, *newSSOToken)
 		accessToken = &newSSOToken.AccessToken
 	} else {
 		accessToken = &cachedToken.AccessToken
@@ -88,7 +102,7 @@ func (c *Profile) SSOLogin(ctx context.Context, configOpts ConfigOpts) (aws.Cred
 		var unauthorised *ssotypes.UnauthorizedException
 		if errors.As(err, &unauthorised) {
 			// possible error with the access token we used, in this case we should clear our cached token and request a new one if the user tries again
-			secureSSOTokenStorage.ClearSSOToken(ssoTokenKey)
+			secureSSOTokenStorage.ClearSSOToken(ctx, ssoTokenKey)
 		}
 		return aws.Credentials{}, err
 	}
@@ -115,10 +129,10 @@ func (c *Profile) SSOLogin(ctx context.Context, configOpts ConfigOpts) (aws.Cred
 				}
 				if p.AWSConfig.MFASerial != "" {
 					aro.SerialNumber = &p.AWSConfig.MFASerial
-					aro.TokenProvider = MfaTokenProvider
+					aro.TokenProvider = MfaTokenProviderFor(ctx, p, p.AWSConfig.MFASerial)
 				} else if c.AWSConfig.MFASerial != "" {
 					aro.SerialNumber = &c.AWSConfig.MFASerial
-					aro.TokenProvider = MfaTokenProvider
+					aro.TokenProvider = MfaTokenProviderFor(ctx, c, c.AWSConfig.MFASerial)
 				}
 				aro.Duration = configOpts.Duration
 				if p.AWSConfig.ExternalID != "" {
@@ -166,7 +180,7 @@ func getOrRegisterClient(ctx context.Context, ssooidcClient *ssooidc.Client, cfg
 		cacheKey = startUrl + pkceCacheKeySuffix
 	}
 
-	cached := regStore.GetValidRegistration(cacheKey)
+	cached := regStore.GetValidRegistration(ctx, cacheKey)
 	if cached != nil {
 		clio.Debugf("using cached SSO client registration (expires %s)", cached.RegistrationExpiresAt.Format(time.RFC3339))
 		return cached, nil
@@ -201,7 +215,7 @@ func getOrRegisterClient(ctx context.Context, ssooidcClient *ssooidc.Client, cfg
 		TokenEndpoint:         aws.ToString(register.TokenEndpoint),
 	}
 
-	regStore.StoreRegistration(cacheKey, *reg)
+	regStore.StoreRegistration(ctx, cacheKey, *reg)
 	return reg, nil
 }
 
@@ -296,6 +310,7 @@ func newSSOTokenFromResponse(token *ssooidc.CreateTokenOutput, reg *securestorag
 		RegistrationExpiresAt: reg.RegistrationExpiresAt,
 		Region:                region,
 		RefreshToken:          token.RefreshToken,
+		IssuedAt:              time.Now(),
 	}
 }
 
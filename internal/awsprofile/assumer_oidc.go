@@ -0,0 +1,265 @@
+package awsprofile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/common-fate/clio"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// scopes requested when the profile does not set aws_fuzzy_oidc_scopes
+	defaultOIDCScopes = "openid"
+
+	// timeout of the discovery and token requests to the identity provider
+	oidcRequestTimeout = 30 * time.Second
+)
+
+// Implements Assumer by logging in to an OpenID Connect identity provider (Okta, Keycloak, ...) in the browser
+// with the authorization code flow and PKCE, the ID token is exchanged with sts:AssumeRoleWithWebIdentity
+//
+//	[profile okta-admin]
+//	aws_fuzzy_oidc_issuer    = https://example.okta.com/oauth2/default
+//	aws_fuzzy_oidc_client_id = 0oa1b2c3d4
+//	aws_fuzzy_oidc_role_arn  = arn:aws:iam::123456789012:role/admin
+type OIDCAssumer struct {
+}
+
+// oidcProviderMetadata contains the endpoints of the OpenID Connect discovery document that are used
+type oidcProviderMetadata struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (oa *OIDCAssumer) AssumeTerminal(ctx context.Context, c *Profile, configOpts ConfigOpts) (aws.Credentials, error) {
+	root := c
+	if len(c.Parents) > 0 {
+		root = c.Parents[0]
+	}
+
+	token, err := oidcLogin(ctx, root, configOpts.PrintOnly)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	input := &sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(root.roleArn()),
+		RoleSessionName:  aws.String(roleSessionName(root)),
+		WebIdentityToken: &token,
+	}
+	if configOpts.Duration > 0 {
+		input.DurationSeconds = aws.Int32(int32(configOpts.Duration.Seconds()))
+	}
+
+	// AssumeRoleWithWebIdentity is not signed, the client does not need credentials
+	out, err := sts.New(sts.Options{Region: stsRegion(ctx, root)}).AssumeRoleWithWebIdentity(ctx, input)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to assume role %s with web identity, %w", root.roleArn(), err)
+	}
+
+	return assumeChildren(ctx, c, TypeCredsToAwsCreds(*out.Credentials), configOpts)
+}
+
+func (oa *OIDCAssumer) AssumeConsole(ctx context.Context, c *Profile, configOpts ConfigOpts) (aws.Credentials, error) {
+	return oa.AssumeTerminal(ctx, c, configOpts)
+}
+
+// A unique key which identifies this assumer e.g AWS-SSO or GOOGLE-AWS-AUTH
+func (oa *OIDCAssumer) Type() string {
+	return ProfileTypeOIDC
+}
+
+// Matches profiles that set an OpenID Connect issuer
+func (oa *OIDCAssumer) ProfileMatchesType(rawProfile *ini.Section, parsedProfile config.SharedConfig) bool {
+	return profileValue(rawProfile, "_oidc_issuer") != ""
+}
+
+// oidcScopes returns the scopes of aws_fuzzy_oidc_scopes, separated by spaces or commas
+func oidcScopes(value string) []string {
+	if value == "" {
+		value = defaultOIDCScopes
+	}
+
+	return strings.Fields(strings.ReplaceAll(value, ",", " "))
+}
+
+// callbackPort returns the port of the local callback server set by the key of the profile or def,
+// identity providers that do not allow any port on loopback redirect URIs need a fixed one
+func callbackPort(rawProfile *ini.Section, suffix string, def int) (int, error) {
+	value := profileValue(rawProfile, suffix)
+	if value == "" {
+		return def, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port '%s' in %s%s", value, afconfig.NewDefaultConfig().AppNameConfig, suffix)
+	}
+
+	return port, nil
+}
+
+// oidcLogin opens the browser to login to the identity provider of the profile and returns the ID token
+func oidcLogin(ctx context.Context, c *Profile, printOnly bool) (string, error) {
+	issuer := profileValue(c.RawConfig, "_oidc_issuer")
+	clientID := profileValue(c.RawConfig, "_oidc_client_id")
+	clientSecret := profileValue(c.RawConfig, "_oidc_client_secret")
+
+	if clientID == "" {
+		return "", fmt.Errorf("profile %s sets an OIDC issuer but no %s_oidc_client_id", c.Name, afconfig.NewDefaultConfig().AppNameConfig)
+	}
+	if c.roleArn() == "" {
+		return "", fmt.Errorf("profile %s sets an OIDC issuer but no %s_oidc_role_arn", c.Name, afconfig.NewDefaultConfig().AppNameConfig)
+	}
+
+	port, err := callbackPort(c.RawConfig, "_oidc_callback_port", 0)
+	if err != nil {
+		return "", err
+	}
+
+	provider, err := discoverOIDCProvider(ctx, issuer)
+	if err != nil {
+		return "", err
+	}
+
+	codeVerifier, err := generateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := generateState()
+	if err != nil {
+		return "", err
+	}
+
+	resultCh := make(chan callbackResult, 1)
+	port, shutdown, err := startLocalServer(fmt.Sprintf("127.0.0.1:%d", port), newCallbackHandler(state, resultCh))
+	if err != nil {
+		return "", err
+	}
+	defer shutdown()
+
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d%s", port, callbackPath)
+	authURL, err := buildAuthorizationURL(provider.AuthorizationEndpoint, clientID, redirectURI, generateCodeChallenge(codeVerifier), state, oidcScopes(profileValue(c.RawConfig, "_oidc_scopes")))
+	if err != nil {
+		return "", fmt.Errorf("building authorization URL: %w", err)
+	}
+
+	afcfg, err := afconfig.NewLoadedConfig()
+	if err != nil {
+		return "", err
+	}
+
+	clio.Infof("Opening browser to login to %s...", issuer)
+	if err := openBrowserForSSO(afcfg, authURL, c.Name, printOnly); err != nil {
+		return "", fmt.Errorf("opening browser: %w", err)
+	}
+
+	clio.Info("Awaiting authentication in the browser...")
+
+	result, err := awaitCallback(ctx, resultCh, shutdown)
+	if err != nil {
+		return "", err
+	}
+	if result.Err != nil {
+		return "", fmt.Errorf("authorization failed: %w", result.Err)
+	}
+
+	form := url.Values{
+		"grant_type":    {GrantTypeAuthCode},
+		"code":          {result.Code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {clientID},
+		"code_verifier": {codeVerifier},
+	}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+
+	return exchangeOIDCCode(ctx, provider.TokenEndpoint, form)
+}
+
+// discoverOIDCProvider fetches the OpenID Connect discovery document of the issuer
+func discoverOIDCProvider(ctx context.Context, issuer string) (*oidcProviderMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, oidcRequestTimeout)
+	defer cancel()
+
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document of %s, %w", issuer, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document of %s, got status %s", issuer, res.Status)
+	}
+
+	var metadata oidcProviderMetadata
+	if err := json.NewDecoder(res.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("invalid OIDC discovery document of %s, %w", issuer, err)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+		return nil, fmt.Errorf("OIDC discovery document of %s has no authorization or token endpoint", issuer)
+	}
+
+	return &metadata, nil
+}
+
+// exchangeOIDCCode exchanges the authorization code for tokens and returns the ID token
+func exchangeOIDCCode(ctx context.Context, tokenEndpoint string, form url.Values) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, oidcRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	var token oidcTokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("exchanging authorization code: invalid response with status %s, %w", res.Status, err)
+	}
+
+	if token.Error != "" {
+		return "", fmt.Errorf("exchanging authorization code: %s: %s", token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return "", errors.New("exchanging authorization code: the identity provider did not return an ID token, check that the openid scope is requested")
+	}
+
+	return token.IDToken, nil
+}
@@ -0,0 +1,286 @@
+package awsprofile
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/granted/pkg/testable"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// port of the local assertion consumer service when the profile does not set aws_fuzzy_saml_callback_port,
+	// the relying party of the identity provider must post the assertion to http://localhost:<port>/saml
+	defaultSAMLCallbackPort = 35001
+
+	samlCallbackPath = "/saml"
+
+	// attribute of the assertion with the roles the user can assume, as "role_arn,principal_arn" pairs
+	samlRoleAttribute = "https://aws.amazon.com/SAML/Attributes/Role"
+)
+
+// Implements Assumer by logging in to a SAML identity provider (ADFS, ...) in the browser, the assertion
+// is posted to a local assertion consumer service and exchanged with sts:AssumeRoleWithSAML
+//
+//	[profile adfs-admin]
+//	aws_fuzzy_saml_url      = https://adfs.example.com/adfs/ls/IdpInitiatedSignOn.aspx?loginToRp=urn:amazon:webservices
+//	aws_fuzzy_saml_role_arn = arn:aws:iam::123456789012:role/admin
+type SAMLAssumer struct {
+}
+
+func (sa *SAMLAssumer) AssumeTerminal(ctx context.Context, c *Profile, configOpts ConfigOpts) (aws.Credentials, error) {
+	root := c
+	if len(c.Parents) > 0 {
+		root = c.Parents[0]
+	}
+
+	assertion, err := samlLogin(ctx, root, configOpts.PrintOnly)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	roleArn, principalArn, err := samlRole(assertion, root.roleArn(), profileValue(root.RawConfig, "_saml_principal_arn"))
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	input := &sts.AssumeRoleWithSAMLInput{
+		RoleArn:       &roleArn,
+		PrincipalArn:  &principalArn,
+		SAMLAssertion: &assertion,
+	}
+	if configOpts.Duration > 0 {
+		input.DurationSeconds = aws.Int32(int32(configOpts.Duration.Seconds()))
+	}
+
+	// AssumeRoleWithSAML is not signed, the client does not need credentials
+	out, err := sts.New(sts.Options{Region: stsRegion(ctx, root)}).AssumeRoleWithSAML(ctx, input)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to assume role %s with SAML, %w", roleArn, err)
+	}
+
+	return assumeChildren(ctx, c, TypeCredsToAwsCreds(*out.Credentials), configOpts)
+}
+
+func (sa *SAMLAssumer) AssumeConsole(ctx context.Context, c *Profile, configOpts ConfigOpts) (aws.Credentials, error) {
+	return sa.AssumeTerminal(ctx, c, configOpts)
+}
+
+// A unique key which identifies this assumer e.g AWS-SSO or GOOGLE-AWS-AUTH
+func (sa *SAMLAssumer) Type() string {
+	return ProfileTypeSAML
+}
+
+// Matches profiles that set a SAML sign-on url
+func (sa *SAMLAssumer) ProfileMatchesType(rawProfile *ini.Section, parsedProfile config.SharedConfig) bool {
+	return profileValue(rawProfile, "_saml_url") != ""
+}
+
+// samlLogin opens the sign-on url of the identity provider and returns the base64 encoded assertion posted back to us,
+// when there is no browser to post it the assertion is asked instead
+func samlLogin(ctx context.Context, c *Profile, printOnly bool) (string, error) {
+	signOnURL := profileValue(c.RawConfig, "_saml_url")
+
+	if IsHeadlessEnvironment() {
+		clio.Warn("Headless environment detected (SSH, container, or CI)")
+		clio.Infof("Login at %s and paste the SAMLResponse posted to the AWS sign-in page", signOnURL)
+		return samlAssertionProvider()
+	}
+
+	port, err := callbackPort(c.RawConfig, "_saml_callback_port", defaultSAMLCallbackPort)
+	if err != nil {
+		return "", err
+	}
+
+	resultCh := make(chan callbackResult, 1)
+	_, shutdown, err := startLocalServer(fmt.Sprintf("127.0.0.1:%d", port), newSAMLCallbackHandler(resultCh))
+	if err != nil {
+		return "", err
+	}
+	defer shutdown()
+
+	afcfg, err := afconfig.NewLoadedConfig()
+	if err != nil {
+		return "", err
+	}
+
+	clio.Info("Opening browser to login to the SAML identity provider...")
+	if err := openBrowserForSSO(afcfg, signOnURL, c.Name, printOnly); err != nil {
+		return "", fmt.Errorf("opening browser: %w", err)
+	}
+
+	clio.Info("Awaiting authentication in the browser...")
+
+	result, err := awaitCallback(ctx, resultCh, shutdown)
+	if err != nil {
+		return "", err
+	}
+	if result.Err != nil {
+		return "", fmt.Errorf("authorization failed: %w", result.Err)
+	}
+
+	return result.Code, nil
+}
+
+func samlAssertionProvider() (string, error) {
+	in := survey.Password{Message: "SAMLResponse"}
+	var out string
+	withStdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+	err := testable.AskOne(&in, &out, withStdio)
+	return strings.TrimSpace(out), err
+}
+
+// newSAMLCallbackHandler creates the HTTP handler of the local assertion consumer service,
+// the assertion is sent on the channel as the code of the result. Only the first request is processed (sync.Once).
+func newSAMLCallbackHandler(resultCh chan<- callbackResult) http.Handler {
+	var once sync.Once
+	mux := http.NewServeMux()
+	mux.HandleFunc(samlCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		// The identity provider posts the assertion with the HTTP-POST binding
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var handled bool
+		once.Do(func() {
+			handled = true
+
+			assertion := r.PostFormValue("SAMLResponse")
+			if assertion == "" {
+				writeErrorPage(w, "missing_assertion", "No SAMLResponse was received.")
+				resultCh <- callbackResult{Err: errors.New("no SAML assertion received")}
+				return
+			}
+
+			setSecurityHeaders(w)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(callbackSuccessHTML))
+			resultCh <- callbackResult{Code: assertion}
+		})
+
+		if !handled {
+			http.Error(w, "Assertion already processed", http.StatusConflict)
+		}
+	})
+	return mux
+}
+
+// samlRoles returns the principal arn of every role of the assertion by role arn
+func samlRoles(assertion string) (map[string]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(assertion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML assertion, %w", err)
+	}
+
+	roles := map[string]string{}
+	inRoleAttribute := false
+
+	decoder := xml.NewDecoder(strings.NewReader(string(raw)))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SAML assertion, %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "Attribute":
+				inRoleAttribute = false
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "Name" && attr.Value == samlRoleAttribute {
+						inRoleAttribute = true
+					}
+				}
+			case "AttributeValue":
+				if !inRoleAttribute {
+					continue
+				}
+
+				var value string
+				if err := decoder.DecodeElement(&value, &element); err != nil {
+					return nil, fmt.Errorf("invalid SAML assertion, %w", err)
+				}
+
+				// the order of the pair is not defined, the principal is the saml-provider
+				var role, principal string
+				for _, arn := range strings.Split(value, ",") {
+					arn = strings.TrimSpace(arn)
+					if strings.Contains(arn, ":saml-provider/") {
+						principal = arn
+					} else {
+						role = arn
+					}
+				}
+				if role != "" && principal != "" {
+					roles[role] = principal
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "Attribute" {
+				inRoleAttribute = false
+			}
+		}
+	}
+
+	return roles, nil
+}
+
+// samlRole returns the role and principal to assume, the role of the profile must be one of the roles of the assertion
+// unless the assertion only has one role, the principal is taken from the assertion if the profile does not set it
+func samlRole(assertion, roleArn, principalArn string) (string, string, error) {
+	if roleArn != "" && principalArn != "" {
+		return roleArn, principalArn, nil
+	}
+
+	roles, err := samlRoles(assertion)
+	if err != nil {
+		return "", "", err
+	}
+
+	if roleArn == "" {
+		if len(roles) != 1 {
+			return "", "", fmt.Errorf("the SAML assertion has %d roles, set %s_saml_role_arn to one of: %s", len(roles), afconfig.NewDefaultConfig().AppNameConfig, strings.Join(sortedKeys(roles), ", "))
+		}
+
+		for role, principal := range roles {
+			return role, principal, nil
+		}
+	}
+
+	principal, ok := roles[roleArn]
+	if !ok {
+		return "", "", fmt.Errorf("role %s is not in the SAML assertion, the roles are: %s", roleArn, strings.Join(sortedKeys(roles), ", "))
+	}
+
+	return roleArn, principal, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
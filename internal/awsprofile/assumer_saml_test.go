@@ -0,0 +1,58 @@
+package awsprofile
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const testSAMLResponse = `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol">
+<Assertion xmlns="urn:oasis:names:tc:SAML:2.0:assertion">
+<AttributeStatement>
+<Attribute Name="https://aws.amazon.com/SAML/Attributes/RoleSessionName">
+<AttributeValue>user@example.com</AttributeValue>
+</Attribute>
+<Attribute Name="https://aws.amazon.com/SAML/Attributes/Role">
+<AttributeValue>arn:aws:iam::123456789012:role/admin,arn:aws:iam::123456789012:saml-provider/ADFS</AttributeValue>
+<AttributeValue>arn:aws:iam::123456789012:saml-provider/ADFS, arn:aws:iam::123456789012:role/readonly</AttributeValue>
+</Attribute>
+</AttributeStatement>
+</Assertion>
+</samlp:Response>`
+
+func TestSAMLRole(t *testing.T) {
+	assertion := base64.StdEncoding.EncodeToString([]byte(testSAMLResponse))
+	provider := "arn:aws:iam::123456789012:saml-provider/ADFS"
+
+	roles, err := samlRoles(assertion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(roles) != 2 {
+		t.Fatalf("got %d roles, want 2: %v", len(roles), roles)
+	}
+
+	role, principal, err := samlRole(assertion, "arn:aws:iam::123456789012:role/readonly", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if role != "arn:aws:iam::123456789012:role/readonly" || principal != provider {
+		t.Errorf("got %s %s", role, principal)
+	}
+
+	_, _, err = samlRole(assertion, "", "")
+	if err == nil || !strings.Contains(err.Error(), "has 2 roles") {
+		t.Errorf("expected an error asking for role_arn, got %v", err)
+	}
+
+	_, _, err = samlRole(assertion, "arn:aws:iam::123456789012:role/missing", "")
+	if err == nil {
+		t.Error("expected an error for a role that is not in the assertion")
+	}
+
+	// the principal of the profile skips parsing the assertion
+	role, principal, err = samlRole("not an assertion", "arn:aws:iam::123456789012:role/admin", provider)
+	if err != nil || role != "arn:aws:iam::123456789012:role/admin" || principal != provider {
+		t.Errorf("got %s %s %v", role, principal, err)
+	}
+}
@@ -3,8 +3,11 @@ package awsprofile
 import (
 	"context"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"gopkg.in/ini.v1"
 )
 
@@ -12,6 +15,8 @@ const (
 	ProfileTypeSSO               = "AWS_SSO"
 	ProfileTypeIAM               = "AWS_IAM"
 	ProfileTypeCredentialProcess = "AWS_CREDENTIAL_PROCESS"
+	ProfileTypeOIDC              = "OIDC_WEB_IDENTITY"
+	ProfileTypeSAML              = "SAML"
 
 	// OAuth 2.0 grant types for SSO OIDC
 	GrantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
@@ -36,7 +41,8 @@ type Assumer interface {
 // List of assumers should be ordered by how they match type
 // specific types should be first, generic types like IAM should be last / the (default)
 // for sso profiles, the internal implementation takes precedence over credential processes
-var assumers []Assumer = []Assumer{&AwsSsoAssumer{}, &CredentialProcessAssumer{}, &AwsIamAssumer{}}
+// external identity providers are matched by their own keys so they must come before IAM
+var assumers []Assumer = []Assumer{&AwsSsoAssumer{}, &CredentialProcessAssumer{}, &OIDCAssumer{}, &SAMLAssumer{}, &AwsIamAssumer{}}
 
 // RegisterAssumer allows assumers to be registered when using this library as a package in other projects
 // position = -1 will append the assumer
@@ -59,3 +65,80 @@ func AssumerFromType(t string) Assumer {
 	}
 	return nil
 }
+
+// profileValue returns the value of the aws-fuzzy specific key of the profile, e.g. "_oidc_issuer" for aws_fuzzy_oidc_issuer
+func profileValue(rawProfile *ini.Section, suffix string) string {
+	if rawProfile == nil {
+		return ""
+	}
+
+	afcfg := afconfig.NewDefaultConfig()
+
+	item, err := rawProfile.GetKey(afcfg.AppNameConfig + suffix)
+	if err != nil {
+		return ""
+	}
+
+	return item.Value()
+}
+
+// assumeChildren assumes the role of every profile between the root profile and c using the credentials of the root,
+// it is used by assumers that only know how to get credentials of the root profile of a source_profile chain
+func assumeChildren(ctx context.Context, c *Profile, rootCreds aws.Credentials, configOpts ConfigOpts) (aws.Credentials, error) {
+	if len(c.Parents) == 0 {
+		return rootCreds, nil
+	}
+
+	creds := rootCreds
+	toAssume := append(append([]*Profile{}, c.Parents[1:]...), c)
+	for _, p := range toAssume {
+		region, err := p.Region(ctx)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+
+		stsClient := sts.New(sts.Options{Credentials: aws.NewCredentialsCache(&CredProv{creds}), Region: region})
+		stsp := stscreds.NewAssumeRoleProvider(stsClient, p.AWSConfig.RoleARN, func(aro *stscreds.AssumeRoleOptions) {
+			if p.AWSConfig.RoleSessionName != "" {
+				aro.RoleSessionName = p.AWSConfig.RoleSessionName
+			} else {
+				aro.RoleSessionName = sessionName()
+			}
+			if p.AWSConfig.MFASerial != "" {
+				aro.SerialNumber = &p.AWSConfig.MFASerial
+				aro.TokenProvider = MfaTokenProviderFor(ctx, p, p.AWSConfig.MFASerial)
+			}
+			if p.AWSConfig.ExternalID != "" {
+				aro.ExternalID = &p.AWSConfig.ExternalID
+			}
+			aro.Duration = configOpts.Duration
+		})
+
+		creds, err = stsp.Retrieve(ctx)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+	}
+
+	return creds, nil
+}
+
+// stsRegion returns the region of the profile, STS is available in every region so any region
+// of the partition works when the profile does not set one
+func stsRegion(ctx context.Context, c *Profile) string {
+	region, err := c.Region(ctx)
+	if err != nil || region == "" {
+		return "us-east-1"
+	}
+
+	return region
+}
+
+// roleSessionName returns the role_session_name of the profile or a unique one
+func roleSessionName(c *Profile) string {
+	if c.AWSConfig.RoleSessionName != "" {
+		return c.AWSConfig.RoleSessionName
+	}
+
+	return sessionName()
+}
@@ -74,7 +74,7 @@ func (p *Profiles) ParseCustomSSOProfile(ctx context.Context, profile *Profile)
 		return nil, err
 	}
 
-	err = validateCredentialProcess(item.Value(), profile.Name)
+	err = ValidateCredentialProcess(item.Value(), profile.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +109,7 @@ func IsValidCustomProfile(rawConfig *ini.Section) error {
 }
 
 // check if the config section has any keys with custom prefix
-func hasCustomSSOPrefix(rawConfig *ini.Section) bool {
+func HasCustomSSOPrefix(rawConfig *ini.Section) bool {
 	afcfg := afconfig.NewDefaultConfig()
 	for _, v := range rawConfig.KeyStrings() {
 		if strings.HasPrefix(v, afcfg.AppNameConfig+"_sso_") {
@@ -119,12 +119,12 @@ func hasCustomSSOPrefix(rawConfig *ini.Section) bool {
 	return false
 }
 
-// validateCredentialProcess checks whether the custom prefixed AWS profiles
+// ValidateCredentialProcess checks whether the custom prefixed AWS profiles
 // are correctly using the credential-process override or not.
 // also check whether the provided flag to 'credential-process --profile pname'
 // matches the AWS config profile name. If it doesn't then return an err
 // as the user will certainly run into unexpected behaviour.
-func validateCredentialProcess(arg string, awsProfileName string) error {
+func ValidateCredentialProcess(arg string, awsProfileName string) error {
 	afcfg := afconfig.NewDefaultConfig()
 	appName := "(" + afcfg.AppName + "|" + strings.ReplaceAll(afcfg.AppName, "-", "_") + ")"
 	regex := regexp.MustCompile(`^(\s+)?` + appName + `\s+sso\s+credential-process.*--profile\s+(?P<PName>([^\s]+))`)
@@ -136,7 +136,7 @@ func validateCredentialProcess(arg string, awsProfileName string) error {
 		profileName := matches[pNameIndex]
 
 		if profileName == "" {
-			return fmt.Errorf("profile name not provided. Try adding profile name like '%s credential-process --profile <profile-name>'", afcfg.AppNameConfig)
+			return fmt.Errorf("profile name not provided. Try adding profile name like '%s sso credential-process --profile <profile-name>'", afcfg.AppName)
 		}
 
 		// if matches then do nth.
@@ -144,8 +144,8 @@ func validateCredentialProcess(arg string, awsProfileName string) error {
 			return nil
 		}
 
-		return fmt.Errorf("unmatched profile names. The profile name '%s' provided to '"+afcfg.AppNameConfig+" credential-process' does not match AWS profile name '%s'", profileName, awsProfileName)
+		return fmt.Errorf("unmatched profile names. The profile name '%s' provided to '"+afcfg.AppName+" sso credential-process' does not match AWS profile name '%s'", profileName, awsProfileName)
 	}
 
-	return fmt.Errorf("unable to parse 'credential_process'. Looks like your credential_process isn't configured correctly. \n You need to add '%s credential-process --profile <profile-name>'", afcfg.AppNameConfig)
+	return fmt.Errorf("unable to parse 'credential_process'. Looks like your credential_process isn't configured correctly. \n You need to add '%s sso credential-process --profile <profile-name>'", afcfg.AppName)
 }
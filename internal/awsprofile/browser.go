@@ -1,16 +1,21 @@
 package awsprofile
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"hash/fnv"
 	neturl "net/url"
 	"os"
+	"strings"
+	"text/template"
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	gassume "github.com/common-fate/granted/pkg/assume"
 	gbrowser "github.com/common-fate/granted/pkg/browser"
 	"github.com/common-fate/granted/pkg/forkprocess"
 	glauncher "github.com/common-fate/granted/pkg/launcher"
+	"gopkg.in/ini.v1"
 )
 
 func LaunchBrowser(url string, profile string, flow string, printOnly bool) error {
@@ -34,18 +39,19 @@ func LaunchBrowser(url string, profile string, flow string, printOnly bool) erro
 		return err
 	}
 
-	var containerName string
 	var l gassume.Launcher
 	finalUrl := url
 
-	if flow == "sso" {
-		if p.AWSConfig.SSOSession != nil {
-			containerName = p.AWSConfig.SSOSession.Name
-		} else {
-			containerName = p.AWSConfig.SSOStartURL
-		}
-	} else {
-		containerName = p.Name
+	// Firefox containers and Chrome profiles are named differently, only one of them is used
+	chrome := false
+	switch cfg.DefaultBrowser {
+	case gbrowser.ChromeKey, gbrowser.BraveKey, gbrowser.EdgeKey, gbrowser.ChromiumKey:
+		chrome = true
+	}
+
+	containerName, err := browserProfileName(cfg, p, flow, chrome)
+	if err != nil {
+		return err
 	}
 
 	switch cfg.DefaultBrowser {
@@ -74,38 +80,12 @@ func LaunchBrowser(url string, profile string, flow string, printOnly bool) erro
 			ExecutablePath: browserPath,
 		}
 
-		color := ""
-		icon := ""
-		if flow == "sso" && p.AWSConfig.SSOSession != nil {
-			s := profiles.sessions[p.AWSConfig.SSOSession.Name]
-			item, err := s.RawConfig.GetKey(cfg.AppNameConfig + "_firefox_color")
-			if err == nil {
-				// https://github.com/onebytegone/granted-containers/blob/main/src/opener/parser.ts#L14
-				// allowed values:
-				// "blue", "turquoise", "green", "yellow", "orange", "red", "pink", "purple"
-				color = item.Value()
-			}
-
-			item, err = s.RawConfig.GetKey(cfg.AppNameConfig + "_firefox_icon")
-			if err == nil {
-				// https://github.com/onebytegone/granted-containers/blob/main/src/opener/parser.ts#L14
-				// allowed values:
-				// "fingerprint", "briefcase", "dollar", "cart", "circle", "gift", "vacation", "food", "fruit", "pet", "tree", "chill"
-				icon = item.Value()
-			}
-		} else {
-			item, err := p.RawConfig.GetKey(cfg.AppNameConfig + "_firefox_color")
-			if err == nil {
-				color = item.Value()
-			}
-
-			item, err = p.RawConfig.GetKey(cfg.AppNameConfig + "_firefox_icon")
-			if err == nil {
-				icon = item.Value()
-			}
+		color, icon, err := profiles.containerStyle(cfg, p, flow)
+		if err != nil {
+			return err
 		}
 
-		finalUrl = fmt.Sprintf("ext+granted-containers:name=%s&url=%s&color=%s&icon=%s", containerName, neturl.QueryEscape(url), color, icon)
+		finalUrl = fmt.Sprintf("ext+granted-containers:name=%s&url=%s&color=%s&icon=%s", neturl.QueryEscape(containerName), neturl.QueryEscape(url), color, icon)
 	case gbrowser.SafariKey:
 		l = glauncher.Safari{}
 	case gbrowser.ArcKey:
@@ -143,3 +123,125 @@ func LaunchBrowser(url string, profile string, flow string, printOnly bool) erro
 	}
 	return nil
 }
+
+// BrowserTemplateData is available to the Firefox container and Chrome profile name templates
+type BrowserTemplateData struct {
+	Profile   string
+	AccountID string
+	Role      string
+	Session   string
+}
+
+// browserSession returns the sso-session name or the start url of the profile, if it uses IAM Identity Center
+func browserSession(p *Profile) string {
+	if p.AWSConfig.SSOSession != nil {
+		return p.AWSConfig.SSOSession.Name
+	}
+
+	return p.AWSConfig.SSOStartURL
+}
+
+// browserProfileName returns the name of the Firefox container or Chrome profile the url is opened in,
+// the IAM Identity Center login is shared by all profiles of the session
+func browserProfileName(cfg afconfig.Config, p *Profile, flow string, chrome bool) (string, error) {
+	if flow == "sso" {
+		return browserSession(p), nil
+	}
+
+	containerTemplate, chromeTemplate := cfg.BrowserTemplates()
+	nameTemplate := containerTemplate
+	if chrome {
+		nameTemplate = chromeTemplate
+	}
+
+	tmpl, err := template.New("browser").Parse(nameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid browser profile template, %w", err)
+	}
+
+	data := BrowserTemplateData{Profile: p.Name, Session: browserSession(p)}
+	data.AccountID, data.Role = p.AccountAndRole()
+
+	out := bytes.NewBufferString("")
+	err = tmpl.Execute(out, data)
+	if err != nil {
+		return "", fmt.Errorf("invalid browser profile template, %w", err)
+	}
+
+	name := strings.TrimSpace(out.String())
+	if name == "" {
+		// e.g. IAM profiles with a template using the account ID
+		return p.Name, nil
+	}
+
+	return name, nil
+}
+
+// containerStyle returns the Firefox container color and icon set on the profile, on its sso-session or in the config,
+// "auto" picks them from the account ID so every account has a distinct container
+func (profiles *Profiles) containerStyle(cfg afconfig.Config, p *Profile, flow string) (string, string, error) {
+	err := cfg.ValidateBrowser()
+	if err != nil {
+		return "", "", err
+	}
+
+	color, icon := cfg.BrowserContainerStyle()
+
+	sections := []*ini.Section{}
+	if p.AWSConfig.SSOSession != nil {
+		if s, ok := profiles.sessions[p.AWSConfig.SSOSession.Name]; ok {
+			sections = append(sections, s.RawConfig)
+		}
+	}
+	if flow != "sso" {
+		// the profile overrides its session, the portal login container only uses the session
+		sections = append(sections, p.RawConfig)
+	}
+
+	for _, section := range sections {
+		if section == nil {
+			continue
+		}
+
+		item, err := section.GetKey(cfg.AppNameConfig + "_firefox_color")
+		if err == nil {
+			color = item.Value()
+			if err := afconfig.ValidateFirefoxColor(color); err != nil {
+				return "", "", fmt.Errorf("%s: %w", section.Name(), err)
+			}
+		}
+
+		item, err = section.GetKey(cfg.AppNameConfig + "_firefox_icon")
+		if err == nil {
+			icon = item.Value()
+			if err := afconfig.ValidateFirefoxIcon(icon); err != nil {
+				return "", "", fmt.Errorf("%s: %w", section.Name(), err)
+			}
+		}
+	}
+
+	key, _ := p.AccountAndRole()
+	if key == "" || flow == "sso" {
+		key = browserSession(p)
+	}
+	if key == "" {
+		key = p.Name
+	}
+
+	if color == afconfig.BrowserAuto {
+		color = afconfig.FirefoxColors[hashIndex(key, len(afconfig.FirefoxColors))]
+	}
+
+	if icon == afconfig.BrowserAuto {
+		// use a different seed, otherwise accounts with the same color would also share the icon
+		icon = afconfig.FirefoxIcons[hashIndex("icon:"+key, len(afconfig.FirefoxIcons))]
+	}
+
+	return color, icon, nil
+}
+
+func hashIndex(key string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,180 @@
+package awsprofile
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/common-fate/clio"
+)
+
+const (
+	// time step and digits used by AWS virtual MFA devices
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+)
+
+var (
+	// the last TOTP code computed for each MFA device, AWS rejects a code that was already used
+	lastTOTPCodes = map[string]string{}
+	lastTOTPMutex sync.Mutex
+)
+
+// MfaTokenProviderFor returns a token provider for the MFA device of the profile, it uses the first available of:
+// the aws_fuzzy_mfa_command of the profile or its parents, the TOTP secret of the device in the keyring or a prompt
+func MfaTokenProviderFor(ctx context.Context, c *Profile, mfaSerial string) func() (string, error) {
+	return func() (string, error) {
+		if command := mfaCommand(c); command != "" {
+			token, err := runMfaCommand(command)
+			if err == nil {
+				return token, nil
+			}
+			clio.Warnf("MFA command of profile %s failed, %s", c.Name, err)
+		}
+
+		if mfaSerial != "" {
+			store := securestorage.NewSecureTOTPSecretStorage()
+			secret, err := store.GetSecret(ctx, mfaSerial)
+			if err == nil {
+				return nextTOTP(mfaSerial, secret)
+			}
+			if !errors.Is(err, securestorage.ErrNotFound) {
+				clio.Debugf("failed to retrieve TOTP secret of %s: %s", mfaSerial, err)
+			}
+		}
+
+		return MfaTokenProvider()
+	}
+}
+
+// mfaCommand returns the aws_fuzzy_mfa_command of the profile or of its nearest parent
+func mfaCommand(c *Profile) string {
+	if command := profileValue(c.RawConfig, "_mfa_command"); command != "" {
+		return command
+	}
+
+	for i := len(c.Parents) - 1; i >= 0; i-- {
+		if command := profileValue(c.Parents[i].RawConfig, "_mfa_command"); command != "" {
+			return command
+		}
+	}
+
+	return ""
+}
+
+// runMfaCommand runs the command with the shell and returns the token it prints,
+// stdin and stderr are passed through so it can ask to touch a YubiKey or for a password
+func runMfaCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	clio.Debugf("running MFA command: %s", command)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(out))
+	if !isMfaToken(token) {
+		return "", fmt.Errorf("expected a numeric MFA token but the command printed '%s'", token)
+	}
+
+	return token, nil
+}
+
+func isMfaToken(token string) bool {
+	if len(token) < 6 || len(token) > 8 {
+		return false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nextTOTP returns the current TOTP code of the MFA device, waiting for the next one if it was already used
+func nextTOTP(mfaSerial, secret string) (string, error) {
+	lastTOTPMutex.Lock()
+	defer lastTOTPMutex.Unlock()
+
+	now := time.Now()
+	code, err := TOTP(secret, now)
+	if err != nil {
+		return "", err
+	}
+
+	if code == lastTOTPCodes[mfaSerial] {
+		wait := totpPeriod - time.Duration(now.UnixNano()%int64(totpPeriod))
+		clio.Infof("Waiting %s for the next MFA code of %s", wait.Round(time.Second), mfaSerial)
+		time.Sleep(wait)
+
+		code, err = TOTP(secret, now.Add(wait))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	lastTOTPCodes[mfaSerial] = code
+	return code, nil
+}
+
+// TOTP computes the RFC 6238 code of the base32 secret at t, as used by AWS virtual MFA devices
+func TOTP(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(totpPeriod.Seconds())))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// RFC 4226 dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// decodeTOTPSecret decodes a base32 secret as shown by the AWS console, spaces and padding are optional
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("invalid TOTP secret, expected a base32 encoded key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,62 @@
+package awsprofile
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 Appendix B test vectors for SHA1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTP(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTP at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPSecretFormat(t *testing.T) {
+	// lower case, spaces and no padding, as copied from the AWS console
+	got, err := TOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "287082" {
+		t.Errorf("got %s, want 287082", got)
+	}
+
+	if _, err := TOTP("not base32!", time.Now()); err == nil {
+		t.Error("expected an error for an invalid secret")
+	}
+}
+
+func TestIsMfaToken(t *testing.T) {
+	for token, want := range map[string]bool{
+		"123456":   true,
+		"12345678": true,
+		"12345":    false,
+		"12a456":   false,
+		"":         false,
+	} {
+		if got := isMfaToken(token); got != want {
+			t.Errorf("isMfaToken(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
@@ -83,7 +83,7 @@ const callbackSuccessHTML = `<!DOCTYPE html>
 <body style="font-family: system-ui, sans-serif; display: flex; justify-content: center; align-items: center; height: 100vh; margin: 0; background: #f8f9fa;">
 <div style="text-align: center; padding: 2rem;">
 <h1 style="color: #16a34a;">Authentication Successful</h1>
-<p>You have successfully authenticated with AWS IAM Identity Center.</p>
+<p>You have successfully authenticated.</p>
 <p>You can close this window and return to your terminal.</p>
 </div>
 </body>
@@ -163,16 +163,28 @@ func newCallbackHandler(expectedState string, resultCh chan<- callbackResult) ht
 // startCallbackServer starts a temporary HTTP server on localhost to receive the PKCE callback.
 // It returns the port, a channel that will receive the authorization code, and a shutdown function.
 func startCallbackServer(expectedState string) (int, <-chan callbackResult, func(), error) {
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	resultCh := make(chan callbackResult, 1)
+
+	port, shutdown, err := startLocalServer("127.0.0.1:0", newCallbackHandler(expectedState, resultCh))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return port, resultCh, shutdown, nil
+}
+
+// startLocalServer serves handler on addr until the returned shutdown function is called,
+// a port of 0 in addr picks a random free port which is returned
+func startLocalServer(addr string, handler http.Handler) (int, func(), error) {
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		return 0, nil, nil, fmt.Errorf("starting callback server: %w", err)
+		return 0, nil, fmt.Errorf("starting callback server: %w", err)
 	}
 
 	port := listener.Addr().(*net.TCPAddr).Port
-	resultCh := make(chan callbackResult, 1)
 
 	server := &http.Server{
-		Handler:      newCallbackHandler(expectedState, resultCh),
+		Handler:      handler,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  30 * time.Second,
@@ -190,7 +202,22 @@ func startCallbackServer(expectedState string) (int, <-chan callbackResult, func
 		_ = server.Shutdown(shutdownCtx)
 	}
 
-	return port, resultCh, shutdown, nil
+	return port, shutdown, nil
+}
+
+// awaitCallback waits for the result of a browser login, the server is shut down before returning
+func awaitCallback(ctx context.Context, resultCh <-chan callbackResult, shutdown func()) (callbackResult, error) {
+	defer shutdown()
+
+	var result callbackResult
+	select {
+	case result = <-resultCh:
+		return result, nil
+	case <-ctx.Done():
+		return result, ctx.Err()
+	case <-time.After(authorizationCallbackTimeout):
+		return result, errors.New("timed out waiting for authorization callback")
+	}
 }
 
 // buildAuthorizationURL constructs the PKCE authorization URL per RFC 6749 and RFC 7636.
@@ -279,19 +306,11 @@ func SSOPKCEFlowFromStartUrl(ctx context.Context, cfg aws.Config, startUrl strin
 	clio.Info("Awaiting authentication in the browser...")
 	clio.Info("You will be prompted to authenticate and approve access")
 
-	var result callbackResult
-	select {
-	case result = <-resultCh:
-	case <-ctx.Done():
-		shutdown()
-		return nil, ctx.Err()
-	case <-time.After(authorizationCallbackTimeout):
-		shutdown()
-		return nil, errors.New("timed out waiting for authorization callback")
-	}
-
 	// Free the port before the token exchange network round-trip.
-	shutdown()
+	result, err := awaitCallback(ctx, resultCh, shutdown)
+	if err != nil {
+		return nil, err
+	}
 
 	if result.Err != nil {
 		return nil, fmt.Errorf("authorization failed: %w", result.Err)
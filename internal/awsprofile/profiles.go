@@ -7,12 +7,14 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
 	"github.com/common-fate/clio"
@@ -59,6 +61,8 @@ var ErrProfileNotInitialised error = errors.New("profile not initialised")
 
 var ErrProfileNotFound error = errors.New("profile not found")
 
+var ErrSSOInstanceNotFound error = errors.New("IAM Identity Center session not found")
+
 type Profiles struct {
 	// alphabetically sorted after first load
 	ProfileNames []string
@@ -80,6 +84,40 @@ func (p *Profile) Description() string {
 	}
 	return key.Value()
 }
+
+// AccountAndRole returns the account and role of an initialised profile, profiles assuming a role belong to the account of the role
+func (p *Profile) AccountAndRole() (string, string) {
+	if roleArn := p.roleArn(); roleArn != "" {
+		role, err := arn.Parse(roleArn)
+		if err == nil {
+			return role.AccountID, strings.TrimPrefix(role.Resource, "role/")
+		}
+	}
+
+	return p.AWSConfig.SSOAccountID, p.AWSConfig.SSORoleName
+}
+
+// roleArn returns the role_arn of the profile or the role assumed with an external identity provider,
+// which has its own key because the AWS SDK expects a source for the credentials of a role_arn
+func (p *Profile) roleArn() string {
+	if p.AWSConfig.RoleARN != "" {
+		return p.AWSConfig.RoleARN
+	}
+
+	for _, suffix := range []string{"_oidc_role_arn", "_saml_role_arn"} {
+		if value := profileValue(p.RawConfig, suffix); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (p *Profiles) HasSession(session string) bool {
+	_, ok := p.sessions[session]
+	return ok
+}
+
 func (p *Profiles) Profile(profile string) (*Profile, error) {
 	if c, ok := p.profiles[profile]; ok {
 		return c, nil
@@ -206,9 +244,13 @@ func IsLegalProfileName(name string) bool {
 // this means that the profile prarent relations are walked and the profile type is determined
 // use this if you need to know the type of every profile in the config
 // for large configuations, this may be expensive
+// errors are recorded in the LoadingError of each profile
 func (p *Profiles) InitialiseProfilesTree(ctx context.Context) {
-	for _, v := range p.profiles {
-		_ = v.init(ctx, p, 0)
+	for name, v := range p.profiles {
+		_, err := p.LoadInitialisedProfile(ctx, name)
+		if err != nil && v.LoadingError == nil {
+			v.LoadingError = err
+		}
 	}
 	/*
 		for _, v := range p.sessions {
@@ -227,7 +269,7 @@ func (p *Profiles) LoadInitialisedProfile(ctx context.Context, profile string) (
 
 	// For config that has a custom prefix we need to convert this to AWS config fields
 	// aws configuration
-	if hasCustomSSOPrefix(pr.RawConfig) {
+	if HasCustomSSOPrefix(pr.RawConfig) {
 		awsConfig, err := p.ParseCustomSSOProfile(ctx, pr)
 		if err != nil {
 			return nil, err
@@ -318,7 +360,12 @@ func (p *Profile) init(ctx context.Context, profiles *Profiles, depth int) error
 		p.Initialised = true
 
 		cfg, err := config.LoadSharedConfigProfile(ctx, p.Name, func(lsco *config.LoadSharedConfigOptions) { lsco.ConfigFiles = []string{p.File} })
+		var assumeRoleErr config.SharedConfigAssumeRoleError
+		if errors.As(err, &assumeRoleErr) && profiles.hasExternalCredentials(assumeRoleErr.Profile) {
+			cfg, err = roleConfigFromSection(p.Name, p.RawConfig)
+		}
 		if err != nil {
+			p.LoadingError = err
 			return err
 		}
 		p.AWSConfig = cfg
@@ -354,6 +401,62 @@ func (p *Profile) init(ctx context.Context, profiles *Profiles, depth int) error
 	return nil
 }
 
+// hasExternalCredentials returns whether the profile gets its credentials from an assumer the AWS SDK does not know about,
+// e.g. an external identity provider
+func (p *Profiles) hasExternalCredentials(profile string) bool {
+	source, ok := p.profiles[profile]
+	if !ok {
+		return false
+	}
+
+	for _, a := range assumers {
+		switch a.Type() {
+		case ProfileTypeSSO, ProfileTypeCredentialProcess, ProfileTypeIAM:
+			continue
+		}
+
+		if a.ProfileMatchesType(source.RawConfig, config.SharedConfig{}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// roleConfigFromSection parses the role keys of a profile, it is used when the AWS SDK refuses to load the profile
+// because its source_profile chain starts with a profile it does not know how to get credentials for
+func roleConfigFromSection(name string, section *ini.Section) (config.SharedConfig, error) {
+	// Section.Key would add the missing keys to the section
+	value := func(key string) string {
+		item, err := section.GetKey(key)
+		if err != nil {
+			return ""
+		}
+		return item.Value()
+	}
+
+	cfg := config.SharedConfig{
+		Profile:           name,
+		RoleARN:           value("role_arn"),
+		SourceProfileName: value("source_profile"),
+		Region:            value("region"),
+		RoleSessionName:   value("role_session_name"),
+		ExternalID:        value("external_id"),
+		MFASerial:         value("mfa_serial"),
+	}
+
+	if seconds := value("duration_seconds"); seconds != "" {
+		n, err := strconv.Atoi(seconds)
+		if err != nil {
+			return config.SharedConfig{}, fmt.Errorf("invalid duration_seconds of profile %s, %w", name, err)
+		}
+		duration := time.Duration(n) * time.Second
+		cfg.RoleDurationSeconds = &duration
+	}
+
+	return cfg, nil
+}
+
 func (s *Session) init(ctx context.Context) error {
 	if !s.Initialised {
 		s.Initialised = true
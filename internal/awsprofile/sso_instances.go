@@ -0,0 +1,82 @@
+package awsprofile
+
+import (
+	"context"
+	"sort"
+)
+
+// SSOInstance is an IAM Identity Center instance used by the profiles, its token is cached with Key
+type SSOInstance struct {
+	// the sso-session name or the start url of legacy profiles
+	Key      string
+	StartURL string
+	Region   string
+	Profiles []string
+}
+
+// SSOInstances returns the IAM Identity Center instances of the sso-session sections and of the sso profiles, sorted by key
+func (p *Profiles) SSOInstances(ctx context.Context) []SSOInstance {
+	instances := make(map[string]*SSOInstance)
+
+	for _, name := range p.SessionNames {
+		s := p.sessions[name]
+		if s.init(ctx) != nil {
+			continue
+		}
+
+		instances[name] = &SSOInstance{Key: name, StartURL: s.AWSConfig.SSOStartURL, Region: s.AWSConfig.SSORegion}
+	}
+
+	for _, name := range p.ProfileNames {
+		profile, err := p.LoadInitialisedProfile(ctx, name)
+		if err != nil || profile.ProfileType != ProfileTypeSSO {
+			continue
+		}
+
+		key := profile.SSOTokenKey()
+		if key == "" {
+			continue
+		}
+
+		instance, ok := instances[key]
+		if !ok {
+			root := profile
+			if len(profile.Parents) > 0 {
+				root = profile.Parents[0]
+			}
+
+			instance = &SSOInstance{Key: key, StartURL: root.AWSConfig.SSOStartURL, Region: root.AWSConfig.SSORegion}
+			if root.AWSConfig.SSOSession != nil {
+				instance.StartURL = root.AWSConfig.SSOSession.SSOStartURL
+				instance.Region = root.AWSConfig.SSOSession.SSORegion
+			}
+			instances[key] = instance
+		}
+
+		instance.Profiles = append(instance.Profiles, name)
+	}
+
+	out := make([]SSOInstance, 0, len(instances))
+	for _, instance := range instances {
+		out = append(out, *instance)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+
+	return out
+}
+
+// SSOInstance returns the instance cached with key
+func (p *Profiles) SSOInstance(ctx context.Context, key string) (*SSOInstance, error) {
+	for _, instance := range p.SSOInstances(ctx) {
+		if instance.Key == key {
+			return &instance, nil
+		}
+	}
+
+	return nil, ErrSSOInstanceNotFound
+}
+
+// ClientRegistrationKeys returns the keys of the client registrations cached for a start url, device code and PKCE
+func ClientRegistrationKeys(startURL string) []string {
+	return []string{startURL, startURL + pkceCacheKeySuffix}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"golang.org/x/term"
+)
+
+func NewClear(entryType, profile string, expired, yes, verbose bool) *Clear {
+	clear := Clear{
+		Type:    entryType,
+		Profile: profile,
+		Expired: expired,
+		Yes:     yes,
+		Verbose: verbose,
+	}
+
+	return &clear
+}
+
+func (p *Clear) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "cacheclear")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	types, err := entryTypes(p.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(types) == 0 {
+		// IAM credentials and TOTP secrets are not cached data, they are only removed when asked for by type
+		types = securestorage.CacheEntryTypes()
+	} else if securestorage.IsPersistent(p.Type) && !p.Yes {
+		confirmed, err := confirm(p.Type)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("aborting by user request")
+		}
+	}
+
+	if p.Profile == "" && !p.Expired {
+		// nothing to filter by key or expiry, the whole services are removed
+		cleared, err := securestorage.ClearEntries(ctx, types...)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Removed %d cached entries", cleared)
+		return nil
+	}
+
+	entries, err := securestorage.ListEntries(ctx, types...)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cleared := 0
+
+	for _, entry := range entries {
+		if p.Profile != "" && !matchesProfile(entry, p.Profile) {
+			continue
+		}
+		// corrupt entries have no expiry, they are removed as well
+		if p.Expired && !entry.Corrupt && !entry.Expired(now) {
+			continue
+		}
+
+		clio.Debugf("removing %s %s", entry.Type, entry.Key)
+		if err := entry.Clear(ctx); err != nil {
+			return fmt.Errorf("failed to remove %s %s, %w", entry.Type, entry.Key, err)
+		}
+		cleared++
+	}
+
+	clio.Successf("Removed %d cached entries", cleared)
+	return nil
+}
+
+// confirm asks before removing secrets that can not be fetched again
+func confirm(entryType string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("%s entries can not be fetched again once removed, use --yes to remove them", entryType)
+	}
+
+	in := survey.Confirm{
+		Message: fmt.Sprintf("%s entries can not be fetched again once removed, the keyring may hold the only copy. Remove them?", entryType),
+		Default: false,
+	}
+	withStdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+
+	var confirmed bool
+	err := survey.AskOne(&in, &confirmed, withStdio)
+
+	return confirmed, err
+}
+
+// matchesProfile reports whether the entry belongs to the profile, credentials are cached by
+// profile name and EKS tokens by profile and cluster name
+func matchesProfile(entry securestorage.Entry, profile string) bool {
+	switch entry.Type {
+	case securestorage.EntryRoleCredentials, securestorage.EntryIAMCredentials:
+		return entry.Key == profile
+	case securestorage.EntryEKSToken:
+		return strings.HasPrefix(entry.Key, profile+":")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	"github.com/hako/durafmt"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewList(entryType string, verbose bool) *List {
+	list := List{
+		Type:    entryType,
+		Verbose: verbose,
+	}
+
+	return &list
+}
+
+func (p *List) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "cachelist")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	types, err := entryTypes(p.Type)
+	if err != nil {
+		return err
+	}
+
+	entries, err := securestorage.ListEntries(ctx, types...)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tKEY\tEXPIRES")
+
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Type, entry.Key, describeEntry(entry))
+	}
+
+	return w.Flush()
+}
+
+// entryTypes validates the type given on the command line, no type selects every type
+func entryTypes(entryType string) ([]string, error) {
+	if entryType == "" {
+		return nil, nil
+	}
+
+	for _, t := range securestorage.EntryTypes() {
+		if t == entryType {
+			return []string{entryType}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown cache entry type '%s', run 'aws-fuzzy cache list --help' to see the types", entryType)
+}
+
+func describeEntry(entry securestorage.Entry) string {
+	if entry.Corrupt {
+		return "corrupt, remove it with 'aws-fuzzy cache clear'"
+	}
+
+	if entry.Expires.IsZero() {
+		return "never"
+	}
+
+	d := time.Until(entry.Expires)
+	if d <= 0 {
+		return fmt.Sprintf("%s (expired)", entry.Expires.Local().Format(time.RFC1123))
+	}
+
+	return fmt.Sprintf("%s (in %s)", entry.Expires.Local().Format(time.RFC1123), durafmt.Parse(d).LimitFirstN(2).String())
+}
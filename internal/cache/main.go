@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/urfave/cli/v2"
+)
+
+type List struct {
+	Type    string
+	Verbose bool
+}
+
+type Clear struct {
+	Type    string
+	Profile string
+	Expired bool
+	Yes     bool
+	Verbose bool
+}
+
+func Command() *cli.Command {
+	types := strings.Join(securestorage.EntryTypes(), ", ")
+
+	command := cli.Command{
+		Name:  "cache",
+		Usage: "Inspect and purge the tokens and credentials cached in the keyring",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the cached entries with their expiry, secrets are not printed",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Usage: fmt.Sprintf("Only list entries of this type (%s)", types)},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					list := NewList(c.String("type"), c.Bool("verbose"))
+
+					return list.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "clear",
+				Usage: "Remove cached entries, every entry except IAM credentials and TOTP secrets if no filter is given",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Usage: fmt.Sprintf("Only remove entries of this type (%s), IAM credentials and TOTP secrets are only removed when their type is given", types)},
+					// not resolved with the profile picker, clearing the whole cache is the default
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "Only remove the role credentials and EKS tokens of this profile, or its IAM credentials with --type iam-credentials"},
+					&cli.BoolFlag{Name: "expired", Usage: "Only remove expired entries"},
+					&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Do not ask for confirmation before removing IAM credentials or TOTP secrets"},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					clear := NewClear(c.String("type"),
+						c.String("profile"),
+						c.Bool("expired"),
+						c.Bool("yes"),
+						c.Bool("verbose"),
+					)
+
+					return clear.Execute(c.Context)
+				},
+			},
+		},
+	}
+
+	return &command
+}
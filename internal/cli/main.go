@@ -6,9 +6,16 @@ import (
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/ssh"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/agent"
+	"github.com/AndreZiviani/aws-fuzzy/internal/cache"
 	"github.com/AndreZiviani/aws-fuzzy/internal/chart"
 	"github.com/AndreZiviani/aws-fuzzy/internal/config"
+	"github.com/AndreZiviani/aws-fuzzy/internal/ecs"
 	"github.com/AndreZiviani/aws-fuzzy/internal/eks"
+	"github.com/AndreZiviani/aws-fuzzy/internal/exec"
+	"github.com/AndreZiviani/aws-fuzzy/internal/iam"
+	"github.com/AndreZiviani/aws-fuzzy/internal/profile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/serve"
 	"github.com/AndreZiviani/aws-fuzzy/internal/ssm"
 	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
 	"github.com/urfave/cli/v2"
@@ -28,25 +35,36 @@ func Run() error {
 		&cli.BoolFlag{Name: "verbose", Usage: "Log debug messages"},
 	}
 
+	// commands with a profile flag show a profile picker when no profile is given
+	commands := profile.WithPicker([]*cli.Command{
+		ssh.Command(),
+		config.Command(),
+		chart.Command(),
+		sso.Command(),
+		ssm.Command(),
+		eks.Command(),
+		ecs.Command(),
+		exec.Command(),
+		iam.Command(),
+		serve.Command(),
+		agent.Command(),
+		profile.Command(),
+	})
+	// the profile of 'cache clear' is an optional filter, the picker is not shown
+	commands = append(commands, cache.Command())
+
 	app := &cli.App{
-		Flags:       flags,
-		Name:        "aws-fuzzy",
-		Usage:       "https://github.com/AndreZiviani/aws-fuzzy",
-		UsageText:   "aws-fuzzy [global options] command [command options] [arguments...]",
-		Version:     version,
-		HideVersion: false,
-		Commands: []*cli.Command{
-			ssh.Command(),
-			config.Command(),
-			chart.Command(),
-			sso.Command(),
-			ssm.Command(),
-			eks.Command(),
-		},
+		Flags:                flags,
+		Name:                 "aws-fuzzy",
+		Usage:                "https://github.com/AndreZiviani/aws-fuzzy",
+		UsageText:            "aws-fuzzy [global options] command [command options] [arguments...]",
+		Version:              version,
+		HideVersion:          false,
+		Commands:             commands,
 		EnableBashCompletion: true,
 	}
 
-	err := app.Run(os.Args)
+	err := app.Run(profile.NormaliseArgs(os.Args))
 	if err != nil {
 		fmt.Println(err)
 
@@ -0,0 +1,208 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// ECS Describe* APIs limit how many resources can be requested at once
+const (
+	describeClustersBatch = 100
+	describeServicesBatch = 10
+	describeTasksBatch    = 100
+)
+
+func GetClusters(ctx context.Context, client *awsecs.Client) ([]Resource, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ecsgetclusters")
+	defer span.Finish()
+
+	arns := make([]string, 0)
+	pag := awsecs.NewListClustersPaginator(client, &awsecs.ListClustersInput{})
+	for pag.HasMorePages() {
+		page, err := pag.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, page.ClusterArns...)
+	}
+
+	clusters := make([]Resource, 0, len(arns))
+	for _, batch := range chunk(arns, describeClustersBatch) {
+		out, err := client.DescribeClusters(ctx, &awsecs.DescribeClustersInput{Clusters: batch})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range out.Clusters {
+			clusters = append(clusters, Cluster{c})
+		}
+	}
+
+	return clusters, nil
+}
+
+func GetServices(ctx context.Context, client *awsecs.Client, cluster string) ([]Resource, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ecsgetservices")
+	defer span.Finish()
+
+	arns := make([]string, 0)
+	pag := awsecs.NewListServicesPaginator(client, &awsecs.ListServicesInput{Cluster: &cluster})
+	for pag.HasMorePages() {
+		page, err := pag.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, page.ServiceArns...)
+	}
+
+	services := make([]Resource, 0, len(arns))
+	for _, batch := range chunk(arns, describeServicesBatch) {
+		out, err := client.DescribeServices(ctx, &awsecs.DescribeServicesInput{Cluster: &cluster, Services: batch})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range out.Services {
+			services = append(services, Service{s})
+		}
+	}
+
+	return services, nil
+}
+
+// GetTasks returns the running tasks of a cluster, optionally filtered by service
+func GetTasks(ctx context.Context, client *awsecs.Client, cluster string, service *string) ([]Resource, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ecsgettasks")
+	defer span.Finish()
+
+	arns := make([]string, 0)
+	pag := awsecs.NewListTasksPaginator(client, &awsecs.ListTasksInput{Cluster: &cluster, ServiceName: service})
+	for pag.HasMorePages() {
+		page, err := pag.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, page.TaskArns...)
+	}
+
+	tasks := make([]Resource, 0, len(arns))
+	for _, batch := range chunk(arns, describeTasksBatch) {
+		out, err := client.DescribeTasks(ctx, &awsecs.DescribeTasksInput{Cluster: &cluster, Tasks: batch})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range out.Tasks {
+			tasks = append(tasks, Task{t})
+		}
+	}
+
+	return tasks, nil
+}
+
+// SelectTask walks the user through cluster -> service -> task
+func SelectTask(ctx context.Context, client *awsecs.Client) (*Cluster, *Task, error) {
+	clusters, err := GetClusters(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(clusters) == 0 {
+		return nil, nil, fmt.Errorf("could not find any ECS cluster")
+	}
+
+	idx, err := choose("Clusters", clusters)
+	if err != nil {
+		return nil, nil, err
+	}
+	cluster := clusters[idx].(Cluster)
+
+	services, err := GetServices(ctx, client, aws.ToString(cluster.ClusterArn))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var serviceName *string
+	if len(services) > 0 {
+		// allow tasks that are not part of a service (e.g. scheduled or standalone tasks) to be selected
+		services = append(services, Service{})
+		idx, err = choose("Services", services)
+		if err != nil {
+			return nil, nil, err
+		}
+		serviceName = services[idx].(Service).ServiceName
+	}
+
+	tasks, err := GetTasks(ctx, client, aws.ToString(cluster.ClusterArn), serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil, fmt.Errorf("could not find any running task")
+	}
+
+	idx, err = choose("Tasks", tasks)
+	if err != nil {
+		return nil, nil, err
+	}
+	task := tasks[idx].(Task)
+
+	return &cluster, &task, nil
+}
+
+// SelectContainer walks the user through cluster -> service -> task -> container
+func SelectContainer(ctx context.Context, client *awsecs.Client) (*Cluster, *Task, *Container, error) {
+	cluster, task, err := SelectTask(ctx, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	containers := make([]Resource, 0, len(task.Containers))
+	for _, c := range task.Containers {
+		containers = append(containers, Container{c})
+	}
+	if len(containers) == 0 {
+		return nil, nil, nil, fmt.Errorf("task %s does not have any container", task.ID())
+	}
+
+	idx, err := choose("Containers", containers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	container := containers[idx].(Container)
+
+	return cluster, task, &container, nil
+}
+
+// SessionTarget returns the SSM target used by ECS Exec and port forwarding sessions
+func SessionTarget(cluster *Cluster, task *Task, container *Container) string {
+	return fmt.Sprintf("ecs:%s_%s_%s", aws.ToString(cluster.ClusterName), task.ID(), aws.ToString(container.RuntimeId))
+}
+
+// choose only prompts the user when there is more than one option
+func choose(title string, resources []Resource) (int, error) {
+	if len(resources) == 1 {
+		return 0, nil
+	}
+
+	return tui(title, resources)
+}
+
+func chunk(items []string, size int) [][]string {
+	chunks := make([][]string, 0, len(items)/size+1)
+	for size < len(items) {
+		items, chunks = items[size:], append(chunks, items[0:size:size])
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
+
+// resource id is the last part of the arn
+// e.g. arn:aws:ecs:us-east-1:123456789012:task/my-cluster/0123456789abcdef
+func arnID(arn string) string {
+	parts := strings.Split(arn, "/")
+	return parts[len(parts)-1]
+}
@@ -0,0 +1,57 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewDescribe(profile, region string) *Describe {
+	describe := Describe{
+		Profile: profile,
+		Region:  region,
+	}
+
+	return &describe
+}
+
+func (p *Describe) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ecs")
+
+	login := sso.Login{Profile: p.Profile}
+
+	creds, err := login.GetCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(p.Region))
+	if err != nil {
+		return err
+	}
+
+	_, task, container, err := SelectContainer(ctx, awsecs.NewFromConfig(cfg))
+	if err != nil {
+		return err
+	}
+
+	span.Finish()
+
+	fmt.Print(task.PrintDetails())
+	fmt.Println("---")
+	fmt.Print(container.PrintDetails())
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/ssm_plugin"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewExec(profile, region, command string) *Exec {
+	exec := Exec{
+		Profile: profile,
+		Region:  region,
+		Command: command,
+	}
+
+	return &exec
+}
+
+func (p *Exec) DoExec(ctx context.Context, client *awsecs.Client, cluster *Cluster, task *Task, container *Container) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ecsexec")
+	defer span.Finish()
+
+	if !task.EnableExecuteCommand {
+		return fmt.Errorf("ECS Exec is not enabled on task %s, enable it with --enable-execute-command on the service or task", task.ID())
+	}
+
+	out, err := client.ExecuteCommand(ctx, &awsecs.ExecuteCommandInput{
+		Cluster:     cluster.ClusterArn,
+		Task:        task.TaskArn,
+		Container:   container.Name,
+		Command:     aws.String(p.Command),
+		Interactive: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	sessionJson, _ := json.Marshal(out.Session)
+	targetJson, _ := json.Marshal(map[string]string{"Target": SessionTarget(cluster, task, container)})
+
+	// ECS Exec sessions are regular SSM sessions handled by the session-manager-plugin
+	return ssm_plugin.RunPlugin(
+		string(sessionJson),
+		p.Region,
+		"StartSession",
+		p.Profile,
+		string(targetJson),
+		fmt.Sprintf("https://ecs.%s.amazonaws.com", p.Region),
+	)
+}
+
+func (p *Exec) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ecs")
+
+	login := sso.Login{Profile: p.Profile}
+
+	creds, err := login.GetCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(p.Region))
+	if err != nil {
+		return err
+	}
+
+	client := awsecs.NewFromConfig(cfg)
+
+	cluster, task, container, err := SelectContainer(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	span.Finish()
+
+	return p.DoExec(ctx, client, cluster, task, container)
+}
@@ -0,0 +1,82 @@
+package ecs
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+type Describe struct {
+	Profile string
+	Region  string
+}
+
+type Exec struct {
+	Profile string
+	Region  string
+	Command string
+}
+
+type PortForward struct {
+	Profile string
+	Region  string
+	Ports   string
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:  "ecs",
+		Usage: "Interact with ECS clusters, services and tasks",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "describe",
+				Usage: "Browse clusters, services, tasks and containers",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use", Value: "us-east-1", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+				},
+				Action: func(c *cli.Context) error {
+					describe := NewDescribe(c.String("profile"),
+						c.String("region"),
+					)
+
+					return describe.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "exec",
+				Usage: "Start an interactive shell on a container using ECS Exec",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use", Value: "us-east-1", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+					&cli.StringFlag{Name: "command", Aliases: []string{"c"}, Value: "/bin/sh", Usage: "What command to run on the container"},
+				},
+				Action: func(c *cli.Context) error {
+					exec := NewExec(c.String("profile"),
+						c.String("region"),
+						c.String("command"),
+					)
+
+					return exec.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "portforward",
+				Usage: "Start a portforwarding session on an ECS task",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use", Value: "us-east-1", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+					&cli.StringFlag{Name: "ports", Value: "8080:localhost:80", Usage: "Binds remote port to local, '<local port>:<remote host>:<remote port>'"},
+				},
+				Action: func(c *cli.Context) error {
+					pf := NewPortForward(c.String("profile"),
+						c.String("region"),
+						c.String("ports"),
+					)
+
+					return pf.Execute(c.Context)
+				},
+			},
+		},
+	}
+
+	return &command
+}
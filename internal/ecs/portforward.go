@@ -0,0 +1,68 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/ssm"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awsecs "github.com/aws/aws-sdk-go-v2/service/ecs"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewPortForward(profile, region, ports string) *PortForward {
+	pf := PortForward{
+		Profile: profile,
+		Region:  region,
+		Ports:   ports,
+	}
+
+	return &pf
+}
+
+func (p *PortForward) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ecs")
+
+	ports := strings.Split(p.Ports, ":")
+	if len(ports) != 3 {
+		return fmt.Errorf("invalid ports '%s', expected '<local port>:<remote host>:<remote port>'", p.Ports)
+	}
+
+	login := sso.Login{Profile: p.Profile}
+
+	creds, err := login.GetCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(p.Region))
+	if err != nil {
+		return err
+	}
+
+	cluster, task, container, err := SelectContainer(ctx, awsecs.NewFromConfig(cfg))
+	if err != nil {
+		return err
+	}
+
+	span.Finish()
+
+	if !task.EnableExecuteCommand {
+		return fmt.Errorf("ECS Exec is not enabled on task %s, it is required for port forwarding", task.ID())
+	}
+
+	// port forwarding to a task uses the same SSM document as EC2 instances, only the target differs
+	pf := ssm.NewPortForward(p.Profile, p.Region, p.Ports)
+
+	return pf.DoPortForward(ctx, SessionTarget(cluster, task, container), ports[0], ports[1], ports[2])
+}
@@ -0,0 +1,288 @@
+package ecs
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/AndreZiviani/fzf-wrapper/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/rivo/tview"
+)
+
+// Resource is anything that can be listed and selected in the TUI
+type Resource interface {
+	PrintName() string
+	PrintDetails() string
+}
+
+type Cluster struct {
+	ecstypes.Cluster
+}
+
+func (c Cluster) PrintName() string {
+	return fmt.Sprintf("%s (%d running tasks)", aws.ToString(c.ClusterName), c.RunningTasksCount)
+}
+
+func (c Cluster) PrintDetails() string {
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Name: %s\n", aws.ToString(c.ClusterName))
+	fmt.Fprintf(output, "Arn: %s\n", aws.ToString(c.ClusterArn))
+	fmt.Fprintf(output, "Status: %s\n", aws.ToString(c.Status))
+	fmt.Fprintf(output, "ActiveServices: %d\n", c.ActiveServicesCount)
+	fmt.Fprintf(output, "RunningTasks: %d\n", c.RunningTasksCount)
+	fmt.Fprintf(output, "PendingTasks: %d\n", c.PendingTasksCount)
+	fmt.Fprintf(output, "ContainerInstances: %d\n", c.RegisteredContainerInstancesCount)
+
+	fmt.Fprintf(output, "CapacityProviders:\n")
+	for _, cp := range c.CapacityProviders {
+		fmt.Fprintf(output, "  - %s\n", cp)
+	}
+
+	return output.String()
+}
+
+type Service struct {
+	ecstypes.Service
+}
+
+func (s Service) PrintName() string {
+	if s.ServiceName == nil {
+		return "<all tasks>"
+	}
+	return fmt.Sprintf("%s (%d/%d)", aws.ToString(s.ServiceName), s.RunningCount, s.DesiredCount)
+}
+
+func (s Service) PrintDetails() string {
+	if s.ServiceName == nil {
+		return "All running tasks in the cluster, including tasks that are not part of a service\n"
+	}
+
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Name: %s\n", aws.ToString(s.ServiceName))
+	fmt.Fprintf(output, "Arn: %s\n", aws.ToString(s.ServiceArn))
+	fmt.Fprintf(output, "Status: %s\n", aws.ToString(s.Status))
+	fmt.Fprintf(output, "TaskDefinition: %s\n", arnID(aws.ToString(s.TaskDefinition)))
+	fmt.Fprintf(output, "LaunchType: %s\n", s.LaunchType)
+	fmt.Fprintf(output, "Desired: %d\n", s.DesiredCount)
+	fmt.Fprintf(output, "Running: %d\n", s.RunningCount)
+	fmt.Fprintf(output, "Pending: %d\n", s.PendingCount)
+	fmt.Fprintf(output, "ExecuteCommand: %t\n", s.EnableExecuteCommand)
+
+	fmt.Fprintf(output, "Deployments:\n")
+	for _, d := range s.Deployments {
+		fmt.Fprintf(output, "  - Id: %s\n    Status: %s\n    Rollout: %s\n    TaskDefinition: %s\n",
+			aws.ToString(d.Id), aws.ToString(d.Status), d.RolloutState, arnID(aws.ToString(d.TaskDefinition)))
+	}
+
+	return output.String()
+}
+
+type Task struct {
+	ecstypes.Task
+}
+
+func (t Task) ID() string {
+	return arnID(aws.ToString(t.TaskArn))
+}
+
+// IPs returns the private IPs attached to the task ENI (awsvpc network mode)
+func (t Task) IPs() []string {
+	ips := make([]string, 0)
+	for _, a := range t.Attachments {
+		for _, d := range a.Details {
+			if aws.ToString(d.Name) == "privateIPv4Address" {
+				ips = append(ips, aws.ToString(d.Value))
+			}
+		}
+	}
+	return ips
+}
+
+func (t Task) PrintName() string {
+	return fmt.Sprintf("%s %s (%s)", t.ID(), arnID(aws.ToString(t.TaskDefinitionArn)), aws.ToString(t.LastStatus))
+}
+
+func (t Task) PrintDetails() string {
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Id: %s\n", t.ID())
+	fmt.Fprintf(output, "TaskDefinition: %s\n", arnID(aws.ToString(t.TaskDefinitionArn)))
+	fmt.Fprintf(output, "Group: %s\n", aws.ToString(t.Group))
+	fmt.Fprintf(output, "LastStatus: %s\n", aws.ToString(t.LastStatus))
+	fmt.Fprintf(output, "DesiredStatus: %s\n", aws.ToString(t.DesiredStatus))
+	fmt.Fprintf(output, "Health: %s\n", t.HealthStatus)
+	fmt.Fprintf(output, "LaunchType: %s\n", t.LaunchType)
+	fmt.Fprintf(output, "AvailabilityZone: %s\n", aws.ToString(t.AvailabilityZone))
+	fmt.Fprintf(output, "Cpu: %s\n", aws.ToString(t.Cpu))
+	fmt.Fprintf(output, "Memory: %s\n", aws.ToString(t.Memory))
+	fmt.Fprintf(output, "ExecuteCommand: %t\n", t.EnableExecuteCommand)
+
+	started := "<not started>"
+	if t.StartedAt != nil {
+		started = t.StartedAt.String()
+	}
+	fmt.Fprintf(output, "StartedAt: %s\n", started)
+
+	fmt.Fprintf(output, "IPs:\n")
+	for _, ip := range t.IPs() {
+		fmt.Fprintf(output, "  - %s\n", ip)
+	}
+
+	fmt.Fprintf(output, "Containers:\n")
+	for _, c := range t.Containers {
+		fmt.Fprintf(output, "  - Name: %s\n    Image: %s\n    Status: %s\n    Health: %s\n",
+			aws.ToString(c.Name), aws.ToString(c.Image), aws.ToString(c.LastStatus), c.HealthStatus)
+	}
+
+	return output.String()
+}
+
+type Container struct {
+	ecstypes.Container
+}
+
+func (c Container) PrintName() string {
+	return fmt.Sprintf("%s (%s)", aws.ToString(c.Name), aws.ToString(c.Image))
+}
+
+func (c Container) PrintDetails() string {
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Name: %s\n", aws.ToString(c.Name))
+	fmt.Fprintf(output, "Image: %s\n", aws.ToString(c.Image))
+	fmt.Fprintf(output, "ImageDigest: %s\n", aws.ToString(c.ImageDigest))
+	fmt.Fprintf(output, "RuntimeId: %s\n", aws.ToString(c.RuntimeId))
+	fmt.Fprintf(output, "LastStatus: %s\n", aws.ToString(c.LastStatus))
+	fmt.Fprintf(output, "Health: %s\n", c.HealthStatus)
+
+	fmt.Fprintf(output, "IPs:\n")
+	for _, n := range c.NetworkInterfaces {
+		fmt.Fprintf(output, "  - %s\n", aws.ToString(n.PrivateIpv4Address))
+	}
+
+	fmt.Fprintf(output, "Ports:\n")
+	for _, b := range c.NetworkBindings {
+		fmt.Fprintf(output, "  - %d:%d/%s\n", aws.ToInt32(b.HostPort), aws.ToInt32(b.ContainerPort), b.Protocol)
+	}
+
+	fmt.Fprintf(output, "ManagedAgents:\n")
+	for _, a := range c.ManagedAgents {
+		fmt.Fprintf(output, "  - %s: %s\n", a.Name, aws.ToString(a.LastStatus))
+	}
+
+	return output.String()
+}
+
+type Tui struct {
+	app             *tview.Application
+	flex            *tview.Flex
+	input           *tview.InputField
+	resourceList    *tview.List
+	resourceDetails *tview.TextView
+	fzf             *fzfwrapper.Wrapper
+	resources       []Resource
+	selected        int
+	resourceIdx     []int
+}
+
+type FzfData struct {
+	Resources []Resource
+}
+
+func (f FzfData) FzfInputList() []string {
+	out := make([]string, 0, f.FzfInputLen())
+
+	for _, r := range f.Resources {
+		out = append(out, r.PrintDetails())
+	}
+
+	return out
+}
+
+func (f FzfData) FzfInputLen() int {
+	return len(f.Resources)
+}
+
+func (t *Tui) resourceListFunc(id int, text string, secondary string, shortcut rune) {
+	t.resourceDetails.SetText(
+		fmt.Sprintf("%s\n", secondary),
+	)
+}
+
+func (t *Tui) inputFunc(text string) {
+	if text == "" {
+		t.resourceList.Clear()
+		last := len(t.resources) - 1
+		for k, v := range t.resources {
+			t.resourceIdx[last-k] = k
+			t.resourceList.InsertItem(
+				-t.resourceList.GetItemCount()-1,
+				v.PrintName(),
+				v.PrintDetails(),
+				0, nil,
+			)
+		}
+		return
+	}
+
+	t.fzf.SetPattern(text)
+	results, _ := t.fzf.Fuzzy()
+
+	t.resourceList.Clear()
+	t.resourceDetails.Clear()
+
+	last := len(results) - 1
+	for k, v := range results {
+		t.resourceIdx[last-k] = int(v.Item.Index())
+		r := t.resources[v.Item.Index()]
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			tview.TranslateANSI(
+				r.PrintName(),
+			),
+			tview.TranslateANSI(v.HighlightResult()),
+			0, nil,
+		)
+	}
+
+	t.resourceList.SetCurrentItem(-1)
+	t.resourceList.SetOffset(0, 0)
+	boldItem(t.resourceList, t.resourceList.GetCurrentItem())
+}
+
+// tui returns the index of the selected resource
+func tui(title string, resources []Resource) (int, error) {
+
+	t := NewTui()
+	t.resourceList.SetTitle(title)
+
+	t.fzf.SetInput(FzfData{Resources: resources})
+	t.resources = resources
+	t.resourceIdx = make([]int, len(t.resources))
+
+	last := len(t.resources) - 1
+
+	for k, v := range t.resources {
+		t.resourceIdx[last-k] = k // reverse order since we are adding items to the beggining of the list
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			v.PrintName(),
+			v.PrintDetails(),
+			0, nil,
+		)
+	}
+
+	if err := t.app.SetRoot(t.flex, true).SetFocus(t.flex).Run(); err != nil {
+		panic(err)
+	}
+
+	if t.selected < 0 {
+		// user aborted the selection (ctrl+c?)
+		return -1, fmt.Errorf("aborting by user request")
+	}
+
+	return t.selected, nil
+}
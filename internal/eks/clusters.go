@@ -0,0 +1,191 @@
+package eks
+
+import (
+	"context"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awseks "github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/common-fate/clio"
+)
+
+// Cluster is an EKS cluster found with one of the profiles
+type Cluster struct {
+	Profile              string
+	Region               string
+	Name                 string
+	Arn                  string
+	Endpoint             string
+	CertificateAuthority string
+	Version              string
+	Status               string
+	VpcID                string
+	PrivateEndpoint      bool
+}
+
+// scope is a profile and region that was searched for clusters
+type scope struct {
+	Profile string
+	Region  string
+}
+
+func (c Cluster) scope() scope {
+	return scope{Profile: c.Profile, Region: c.Region}
+}
+
+// discoverClusters returns the clusters of every profile and region and the scopes that were searched
+// successfully, profiles without credentials or permissions are skipped with a warning. Without profile names
+// only the profiles with cached credentials are searched, logging in to every profile could open the browser
+// or ask for an MFA token for each of them
+func discoverClusters(ctx context.Context, profileNames, regions []string) ([]Cluster, map[scope]bool, error) {
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cacheOnly := len(profileNames) == 0
+	if cacheOnly {
+		profileNames = profiles.ProfileNames
+	}
+
+	credstore := securestorage.NewSecureRoleCredentialsStorage()
+	skipped := 0
+
+	clusters := []Cluster{}
+	searched := map[scope]bool{}
+
+	for _, name := range profileNames {
+		var creds *aws.Credentials
+		if cacheOnly {
+			creds = credstore.GetValidCredentials(ctx, name)
+			if creds == nil {
+				clio.Debugf("skipping profile %s, no cached credentials", name)
+				skipped++
+				continue
+			}
+		}
+
+		profileRegions := regions
+		if len(profileRegions) == 0 {
+			region, err := profileRegion(ctx, profiles, name)
+			if err != nil {
+				clio.Warnf("skipping profile %s, %s", name, err)
+				continue
+			}
+			profileRegions = []string{region}
+		}
+
+		if creds == nil {
+			login := sso.Login{Profile: name}
+			creds, err = login.GetCredentials(ctx)
+			if err != nil {
+				clio.Warnf("skipping profile %s, %s", name, err)
+				continue
+			}
+		}
+
+		for _, region := range profileRegions {
+			found, err := listClusters(ctx, creds, name, region)
+			if err != nil {
+				clio.Warnf("skipping region %s of profile %s, %s", region, name, err)
+				continue
+			}
+
+			clio.Debugf("found %d clusters in region %s of profile %s", len(found), region, name)
+
+			searched[scope{Profile: name, Region: region}] = true
+			clusters = append(clusters, found...)
+		}
+	}
+
+	if skipped > 0 {
+		clio.Infof("Skipped %d profiles without cached credentials, login to them first or choose them with --profiles", skipped)
+	}
+
+	return clusters, searched, nil
+}
+
+// resolveRegion returns the given region or the region of the profile
+func resolveRegion(ctx context.Context, profile, region string) (string, error) {
+	if region != "" {
+		return region, nil
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return "", err
+	}
+
+	return profileRegion(ctx, profiles, profile)
+}
+
+// profileRegion returns the region of the profile
+func profileRegion(ctx context.Context, profiles *awsprofile.Profiles, name string) (string, error) {
+	profile, err := profiles.LoadInitialisedProfile(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	return profile.Region(ctx)
+}
+
+// listClusters returns the clusters of a region
+func listClusters(ctx context.Context, creds *aws.Credentials, profile, region string) ([]Cluster, error) {
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	client := awseks.NewFromConfig(cfg)
+
+	clusters := []Cluster{}
+
+	paginator := awseks.NewListClustersPaginator(client, &awseks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range page.Clusters {
+			cluster, err := describeCluster(ctx, client, profile, region, name)
+			if err != nil {
+				return nil, err
+			}
+
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	return clusters, nil
+}
+
+// describeCluster returns the cluster with the details needed to connect to it
+func describeCluster(ctx context.Context, client *awseks.Client, profile, region, name string) (Cluster, error) {
+	out, err := client.DescribeCluster(ctx, &awseks.DescribeClusterInput{Name: aws.String(name)})
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	cluster := Cluster{
+		Profile:  profile,
+		Region:   region,
+		Name:     name,
+		Arn:      aws.ToString(out.Cluster.Arn),
+		Endpoint: aws.ToString(out.Cluster.Endpoint),
+		Version:  aws.ToString(out.Cluster.Version),
+		Status:   string(out.Cluster.Status),
+	}
+	if out.Cluster.CertificateAuthority != nil {
+		cluster.CertificateAuthority = aws.ToString(out.Cluster.CertificateAuthority.Data)
+	}
+	if out.Cluster.ResourcesVpcConfig != nil {
+		cluster.VpcID = aws.ToString(out.Cluster.ResourcesVpcConfig.VpcId)
+		cluster.PrivateEndpoint = out.Cluster.ResourcesVpcConfig.EndpointPrivateAccess
+	}
+
+	return cluster, nil
+}
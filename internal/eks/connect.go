@@ -0,0 +1,238 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/exec"
+	"github.com/AndreZiviani/aws-fuzzy/internal/ssm"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awseks "github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// port of the EKS API endpoint
+const apiPort = "443"
+
+func NewConnect(profile, clusterName, region, roleArn, sessionName, instance string, port int, command []string, verbose bool) *Connect {
+	return &Connect{
+		Profile:     profile,
+		ClusterName: clusterName,
+		Region:      region,
+		RoleArn:     roleArn,
+		SessionName: sessionName,
+		Instance:    instance,
+		Port:        port,
+		Command:     command,
+		Verbose:     verbose,
+	}
+}
+
+// connectArgs returns the command to run, the cluster is given with --cluster-name so every argument belongs
+// to the command. urfave/cli usually strips the '--' separator, one that is left is not part of the command
+func connectArgs(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	return args
+}
+
+func (p *Connect) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "eksconnect")
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	region, err := resolveRegion(ctx, p.Profile, p.Region)
+	if err != nil {
+		return err
+	}
+
+	login := sso.Login{Profile: p.Profile}
+	creds, err := login.GetCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(region))
+	if err != nil {
+		return err
+	}
+
+	cluster, err := p.selectCluster(ctx, cfg, creds, region)
+	if err != nil {
+		return err
+	}
+
+	if !cluster.PrivateEndpoint {
+		clio.Warnf("The API endpoint of %s is not private, it is reached through the internet access of the VPC", cluster.Name)
+	}
+
+	endpoint, err := url.Parse(cluster.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint of cluster %s, %w", cluster.Name, err)
+	}
+
+	instance := p.Instance
+	if instance == "" {
+		instance, err = findInstance(ctx, cfg, cluster.VpcID)
+		if err != nil {
+			return err
+		}
+	}
+
+	port := p.Port
+	if port == 0 {
+		port, err = freePort()
+		if err != nil {
+			return err
+		}
+	}
+
+	clio.Debugf("forwarding 127.0.0.1:%d to %s:%s through %s", port, endpoint.Hostname(), apiPort, instance)
+
+	pf := ssm.NewPortForward(p.Profile, region, "")
+	session, err := pf.StartPortForward(ctx, instance, strconv.Itoa(port), endpoint.Hostname(), apiPort)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = session.Close(context.Background()) }()
+
+	dir, err := os.MkdirTemp("", "aws-fuzzy-eks-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "config")
+	err = tunnelKubeconfig(cluster, port, p.RoleArn, p.SessionName).save(path)
+	if err != nil {
+		return err
+	}
+
+	span.Finish()
+
+	command := p.Command
+	if len(command) == 0 {
+		command = []string{shell()}
+	}
+
+	clio.Infof("Connected to %s through %s, exit the command to close the connection", cluster.Name, instance)
+
+	return exec.Run(command, append(os.Environ(), "KUBECONFIG="+path))
+}
+
+// selectCluster returns the given cluster or the one selected in the picker
+func (p *Connect) selectCluster(ctx context.Context, cfg aws.Config, creds *aws.Credentials, region string) (Cluster, error) {
+	if p.ClusterName != "" {
+		return describeCluster(ctx, awseks.NewFromConfig(cfg), p.Profile, region, p.ClusterName)
+	}
+
+	clusters, err := listClusters(ctx, creds, p.Profile, region)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	if len(clusters) == 0 {
+		return Cluster{}, fmt.Errorf("no EKS clusters found in region %s", region)
+	}
+
+	resources := make([]Resource, 0, len(clusters))
+	for _, c := range clusters {
+		resources = append(resources, c)
+	}
+
+	idx, err := choose("Clusters", resources)
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	return clusters[idx], nil
+}
+
+// findInstance returns a running instance of the VPC with the SSM agent online
+func findInstance(ctx context.Context, cfg aws.Config, vpcID string) (string, error) {
+	instances, err := ssm.GetInstances(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	for _, reservation := range instances.Reservations {
+		for _, instance := range reservation.Instances {
+			if aws.ToString(instance.VpcId) == vpcID {
+				return aws.ToString(instance.InstanceId), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no running instance with the SSM agent online in %s, choose one with --instance", vpcID)
+}
+
+// tunnelKubeconfig returns a kubeconfig with a single context that reaches the cluster through the local port,
+// the certificate of the API server is still verified for the name of its endpoint
+func tunnelKubeconfig(c Cluster, port int, roleArn, sessionName string) *kubeconfig {
+	k := &kubeconfig{APIVersion: "v1", Kind: "Config"}
+	name := contextNames([]Cluster{c})[0]
+
+	tunnel := c
+	tunnel.Endpoint = "https://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	k.upsert(name, tunnel)
+
+	if endpoint, err := url.Parse(c.Endpoint); err == nil {
+		k.cluster(name).Cluster["tls-server-name"] = endpoint.Hostname()
+	}
+
+	if roleArn != "" {
+		user := k.user(name)
+		user.User.Exec.Args = append(user.User.Exec.Args, "--role-arn", roleArn, "--session-name", sessionName)
+	}
+
+	k.CurrentContext = name
+
+	return k
+}
+
+// freePort returns a local port that is not in use
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// shell returns the shell of the user
+func shell() string {
+	if s := os.Getenv("SHELL"); s != "" {
+		return s
+	}
+	if s := os.Getenv("ComSpec"); s != "" {
+		return s
+	}
+
+	return "/bin/sh"
+}
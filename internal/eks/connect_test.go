@@ -0,0 +1,57 @@
+package eks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConnectArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command []string
+	}{
+		{nil, nil},
+		// 'connect -- k9s', urfave/cli strips the separator
+		{[]string{"k9s"}, []string{"k9s"}},
+		{[]string{"kubectl", "get", "pods"}, []string{"kubectl", "get", "pods"}},
+		{[]string{"--", "kubectl", "get", "pods"}, []string{"kubectl", "get", "pods"}},
+		{[]string{"--"}, nil},
+	}
+
+	for _, tt := range tests {
+		command := connectArgs(tt.args)
+		if !reflect.DeepEqual(command, tt.command) {
+			t.Errorf("connectArgs(%v) = %v, want %v", tt.args, command, tt.command)
+		}
+	}
+}
+
+func TestTunnelKubeconfig(t *testing.T) {
+	c := Cluster{
+		Profile:              "dev",
+		Region:               "us-east-1",
+		Name:                 "main",
+		Endpoint:             "https://0123456789ABCDEF.gr7.us-east-1.eks.amazonaws.com",
+		CertificateAuthority: "Y2E=",
+	}
+
+	k := tunnelKubeconfig(c, 41231, "arn:aws:iam::123456789012:role/admin", "me")
+
+	if k.CurrentContext != "dev/main" {
+		t.Errorf("got current context %q", k.CurrentContext)
+	}
+
+	cluster := k.cluster("dev/main").Cluster
+	if cluster["server"] != "https://127.0.0.1:41231" {
+		t.Errorf("got server %v", cluster["server"])
+	}
+	if cluster["tls-server-name"] != "0123456789ABCDEF.gr7.us-east-1.eks.amazonaws.com" {
+		t.Errorf("got tls-server-name %v", cluster["tls-server-name"])
+	}
+
+	args := strings.Join(k.user("dev/main").User.Exec.Args, " ")
+	if args != "eks get-token --profile dev --cluster-name main --region us-east-1 --role-arn arn:aws:iam::123456789012:role/admin --session-name me" {
+		t.Errorf("got args %s", args)
+	}
+}
@@ -0,0 +1,329 @@
+package eks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ExecCredential version requested by the contexts, kubectl passes it to get-token in KUBERNETES_EXEC_INFO
+	execAPIVersion = "client.authentication.k8s.io/v1beta1"
+	execCommand    = "aws-fuzzy"
+)
+
+// kubeconfig is the subset of a kubeconfig file that we manage, every other field is kept as is
+type kubeconfig struct {
+	APIVersion     string                 `yaml:"apiVersion"`
+	Kind           string                 `yaml:"kind"`
+	CurrentContext string                 `yaml:"current-context"`
+	Clusters       []namedCluster         `yaml:"clusters"`
+	Contexts       []namedContext         `yaml:"contexts"`
+	Users          []namedUser            `yaml:"users"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+type namedCluster struct {
+	Name    string                 `yaml:"name"`
+	Cluster map[string]interface{} `yaml:"cluster"`
+}
+
+type namedContext struct {
+	Name    string      `yaml:"name"`
+	Context kubeContext `yaml:"context"`
+}
+
+type kubeContext struct {
+	Cluster string                 `yaml:"cluster"`
+	User    string                 `yaml:"user"`
+	Extra   map[string]interface{} `yaml:",inline"`
+}
+
+type namedUser struct {
+	Name string   `yaml:"name"`
+	User kubeUser `yaml:"user"`
+}
+
+type kubeUser struct {
+	Exec  *execConfig            `yaml:"exec,omitempty"`
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+type execConfig struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Command    string                 `yaml:"command"`
+	Args       []string               `yaml:"args"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// kubeconfigPath returns the given path, the first file of $KUBECONFIG or ~/.kube/config
+func kubeconfigPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if p != "" {
+			return p, nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+// loadKubeconfig reads a kubeconfig file, a missing file is loaded as an empty one
+func loadKubeconfig(path string) (*kubeconfig, error) {
+	k := kubeconfig{APIVersion: "v1", Kind: "Config"}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(b, &k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s, %w", path, err)
+	}
+
+	return &k, nil
+}
+
+// save replaces the kubeconfig file, it holds credentials so it is only readable by the user
+func (k *kubeconfig) save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(k)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so kubectl never sees a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(buf.Bytes())
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// execArgs returns the arguments of the get-token command of the cluster
+func execArgs(c Cluster) []string {
+	return []string{"eks", "get-token", "--profile", c.Profile, "--cluster-name", c.Name, "--region", c.Region}
+}
+
+// contextNames returns the context name of each cluster, <profile>/<cluster> unless the profile
+// has clusters with the same name in different regions, then <profile>/<region>/<cluster>
+func contextNames(clusters []Cluster) []string {
+	count := map[string]int{}
+	for _, c := range clusters {
+		count[c.Profile+"/"+c.Name]++
+	}
+
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		name := c.Profile + "/" + c.Name
+		if count[name] > 1 {
+			name = c.Profile + "/" + c.Region + "/" + c.Name
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// upsert adds or updates the cluster, user and context of the cluster, all named after the context,
+// and returns whether the context is new
+func (k *kubeconfig) upsert(name string, c Cluster) bool {
+	cluster := k.cluster(name)
+	if cluster == nil {
+		k.Clusters = append(k.Clusters, namedCluster{Name: name})
+		cluster = &k.Clusters[len(k.Clusters)-1]
+	}
+	if cluster.Cluster == nil {
+		cluster.Cluster = map[string]interface{}{}
+	}
+	cluster.Cluster["server"] = c.Endpoint
+	cluster.Cluster["certificate-authority-data"] = c.CertificateAuthority
+
+	user := k.user(name)
+	if user == nil {
+		k.Users = append(k.Users, namedUser{Name: name})
+		user = &k.Users[len(k.Users)-1]
+	}
+	if user.User.Exec == nil {
+		user.User.Exec = &execConfig{}
+	}
+	// keep options added by hand, e.g. --role-arn in the arguments or env and interactiveMode
+	exec := user.User.Exec
+	exec.APIVersion = execAPIVersion
+	exec.Command = execCommand
+	exec.Args = append(execArgs(c), extraArgs(exec.Args)...)
+
+	context := k.context(name)
+	added := context == nil
+	if added {
+		k.Contexts = append(k.Contexts, namedContext{Name: name})
+		context = &k.Contexts[len(k.Contexts)-1]
+	}
+	context.Context.Cluster = name
+	context.Context.User = name
+
+	return added
+}
+
+// prune removes the contexts of clusters that were not found in a scope that was searched and returns their names,
+// only users that run aws-fuzzy get-token are considered
+func (k *kubeconfig) prune(clusters []Cluster, searched map[scope]bool) []string {
+	found := map[string]bool{}
+	for _, c := range clusters {
+		found[fmt.Sprint(execArgs(c))] = true
+	}
+
+	stale := map[string]bool{}
+	users := k.Users[:0]
+	for _, u := range k.Users {
+		c, ok := managedCluster(u.User)
+		if ok && searched[c.scope()] && !found[fmt.Sprint(execArgs(c))] {
+			stale[u.Name] = true
+			continue
+		}
+		users = append(users, u)
+	}
+	k.Users = users
+
+	removed := []string{}
+	staleClusters := map[string]bool{}
+	contexts := k.Contexts[:0]
+	for _, c := range k.Contexts {
+		if stale[c.Context.User] {
+			removed = append(removed, c.Name)
+			staleClusters[c.Context.Cluster] = true
+			if k.CurrentContext == c.Name {
+				k.CurrentContext = ""
+			}
+			continue
+		}
+		contexts = append(contexts, c)
+	}
+	k.Contexts = contexts
+
+	// clusters are only removed if no other context uses them
+	for _, c := range k.Contexts {
+		delete(staleClusters, c.Context.Cluster)
+	}
+	namedClusters := k.Clusters[:0]
+	for _, c := range k.Clusters {
+		if !staleClusters[c.Name] {
+			namedClusters = append(namedClusters, c)
+		}
+	}
+	k.Clusters = namedClusters
+
+	return removed
+}
+
+// managedCluster returns the cluster of a user that runs aws-fuzzy eks get-token
+func managedCluster(u kubeUser) (Cluster, bool) {
+	if u.Exec == nil || filepath.Base(u.Exec.Command) != execCommand {
+		return Cluster{}, false
+	}
+
+	args := u.Exec.Args
+	if len(args) < 2 || args[0] != "eks" || args[1] != "get-token" {
+		return Cluster{}, false
+	}
+
+	c := Cluster{}
+	for i := 2; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "--profile", "-p":
+			c.Profile = args[i+1]
+		case "--cluster-name", "-c":
+			c.Name = args[i+1]
+		case "--region", "-r":
+			c.Region = args[i+1]
+		}
+	}
+
+	if c.Profile == "" || c.Name == "" || c.Region == "" {
+		return Cluster{}, false
+	}
+
+	return c, true
+}
+
+// extraArgs returns the options of a get-token command that are not set by execArgs
+func extraArgs(args []string) []string {
+	if len(args) < 2 || args[0] != "eks" || args[1] != "get-token" {
+		return nil
+	}
+
+	extra := []string{}
+	for i := 2; i < len(args); i++ {
+		switch args[i] {
+		case "--profile", "-p", "--cluster-name", "-c", "--region", "-r":
+			i++ // skip the value
+		default:
+			extra = append(extra, args[i])
+		}
+	}
+
+	return extra
+}
+
+func (k *kubeconfig) cluster(name string) *namedCluster {
+	for i := range k.Clusters {
+		if k.Clusters[i].Name == name {
+			return &k.Clusters[i]
+		}
+	}
+	return nil
+}
+
+func (k *kubeconfig) user(name string) *namedUser {
+	for i := range k.Users {
+		if k.Users[i].Name == name {
+			return &k.Users[i]
+		}
+	}
+	return nil
+}
+
+func (k *kubeconfig) context(name string) *namedContext {
+	for i := range k.Contexts {
+		if k.Contexts[i].Name == name {
+			return &k.Contexts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package eks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const existingKubeconfig = `apiVersion: v1
+kind: Config
+current-context: minikube
+preferences: {}
+clusters:
+  - name: minikube
+    cluster:
+      server: https://192.168.49.2:8443
+  - name: dev/gone
+    cluster:
+      server: https://gone.example.com
+  - name: prod/gone
+    cluster:
+      server: https://gone.example.com
+contexts:
+  - name: minikube
+    context:
+      cluster: minikube
+      user: minikube
+      namespace: default
+  - name: dev/gone
+    context:
+      cluster: dev/gone
+      user: dev/gone
+  - name: prod/gone
+    context:
+      cluster: prod/gone
+      user: prod/gone
+users:
+  - name: minikube
+    user:
+      client-certificate: /home/user/.minikube/client.crt
+  - name: dev/gone
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1beta1
+        command: aws-fuzzy
+        args: [eks, get-token, --profile, dev, --cluster-name, gone, --region, us-east-1]
+  - name: prod/gone
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1beta1
+        command: aws-fuzzy
+        args: [eks, get-token, --profile, prod, --cluster-name, gone, --region, us-east-1]
+`
+
+func TestUpdateKubeconfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(existingKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := loadKubeconfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clusters := []Cluster{
+		{Profile: "dev", Region: "us-east-1", Name: "main", Endpoint: "https://main.example.com", CertificateAuthority: "Y2E="},
+	}
+
+	if !k.upsert("dev/main", clusters[0]) {
+		t.Error("expected a new context")
+	}
+
+	// options added by hand are kept
+	user := k.user("dev/main")
+	user.User.Exec.Args = append(user.User.Exec.Args, "--role-arn", "arn:aws:iam::123456789012:role/admin")
+
+	clusters[0].Endpoint = "https://new.example.com"
+	if k.upsert("dev/main", clusters[0]) {
+		t.Error("expected the context to be updated")
+	}
+
+	// prod was not searched so its context is kept
+	removed := k.prune(clusters, map[scope]bool{{Profile: "dev", Region: "us-east-1"}: true})
+	if !reflect.DeepEqual(removed, []string{"dev/gone"}) {
+		t.Errorf("got %v, want dev/gone removed", removed)
+	}
+
+	if err := k.save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k, err = loadKubeconfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if k.CurrentContext != "minikube" {
+		t.Errorf("got current context %q", k.CurrentContext)
+	}
+	if k.cluster("dev/gone") != nil || k.user("dev/gone") != nil || k.context("dev/gone") != nil {
+		t.Error("expected dev/gone to be removed")
+	}
+	if k.context("prod/gone") == nil {
+		t.Error("expected prod/gone to be kept")
+	}
+	if ns := k.context("minikube").Context.Extra["namespace"]; ns != "default" {
+		t.Errorf("got namespace %v, want unknown fields to be kept", ns)
+	}
+	if server := k.cluster("dev/main").Cluster["server"]; server != "https://new.example.com" {
+		t.Errorf("got server %v", server)
+	}
+
+	exec := k.user("dev/main").User.Exec
+	if exec == nil || exec.Command != "aws-fuzzy" || strings.Join(exec.Args, " ") != "eks get-token --profile dev --cluster-name main --region us-east-1 --role-arn arn:aws:iam::123456789012:role/admin" {
+		t.Errorf("got exec %+v", exec)
+	}
+
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), "preferences: {}") {
+		t.Errorf("expected unknown top level fields to be kept, got\n%s", b)
+	}
+}
+
+func TestUpsertKeepsExecOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(path, []byte(`apiVersion: v1
+kind: Config
+users:
+  - name: dev/main
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1beta1
+        command: /usr/local/bin/aws-fuzzy
+        args: [eks, get-token, --profile, dev, --cluster-name, main, --region, us-east-1]
+        env:
+          - name: AWSFUZZY_KEYRING_BACKEND
+            value: file
+        interactiveMode: Never
+        provideClusterInfo: true
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := loadKubeconfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k.upsert("dev/main", Cluster{Profile: "dev", Region: "us-east-1", Name: "main", Endpoint: "https://main.example.com", CertificateAuthority: "Y2E="})
+
+	if err := k.save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	k, err = loadKubeconfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exec := k.user("dev/main").User.Exec
+	if exec.Command != "aws-fuzzy" || exec.APIVersion != execAPIVersion {
+		t.Errorf("got command %s and apiVersion %s", exec.Command, exec.APIVersion)
+	}
+	if exec.Extra["interactiveMode"] != "Never" || exec.Extra["provideClusterInfo"] != true {
+		t.Errorf("got extra %+v, want the options added by hand to be kept", exec.Extra)
+	}
+	if env, ok := exec.Extra["env"].([]interface{}); !ok || len(env) != 1 {
+		t.Errorf("got env %+v", exec.Extra["env"])
+	}
+}
+
+func TestContextNames(t *testing.T) {
+	clusters := []Cluster{
+		{Profile: "dev", Region: "us-east-1", Name: "main"},
+		{Profile: "dev", Region: "eu-west-1", Name: "main"},
+		{Profile: "prod", Region: "us-east-1", Name: "main"},
+	}
+
+	want := []string{"dev/us-east-1/main", "dev/eu-west-1/main", "prod/main"}
+	if got := contextNames(clusters); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -8,10 +8,42 @@ type GetToken struct {
 	Profile     string
 	ClusterName string
 	Region      string
+	RoleArn     string
+	SessionName string
 	NoCache     bool
 	Verbose     bool
 }
 
+type WhoAmI struct {
+	Profile     string
+	ClusterName string
+	Region      string
+	RoleArn     string
+	SessionName string
+	Verbose     bool
+}
+
+type Connect struct {
+	Profile     string
+	ClusterName string
+	Region      string
+	RoleArn     string
+	SessionName string
+	Instance    string
+	Port        int
+	Command     []string
+	Verbose     bool
+}
+
+type UpdateKubeconfig struct {
+	Profiles   []string
+	Regions    []string
+	Kubeconfig string
+	All        bool
+	Prune      bool
+	Verbose    bool
+}
+
 func Command() *cli.Command {
 	command := cli.Command{
 		Name:  "eks",
@@ -24,6 +56,8 @@ func Command() *cli.Command {
 					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
 					&cli.StringFlag{Name: "cluster-name", Aliases: []string{"c"}, Usage: "The name of the EKS cluster", Required: true},
 					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+					&cli.StringFlag{Name: "role-arn", Usage: "Role to assume with the credentials of the profile before generating the token"},
+					&cli.StringFlag{Name: "session-name", Usage: "Session name of the assumed role", Value: defaultSessionName},
 					&cli.BoolFlag{Name: "no-cache", Aliases: []string{"n"}, Usage: "Don't use cached EKS token"},
 					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
 				},
@@ -32,12 +66,89 @@ func Command() *cli.Command {
 						c.String("profile"),
 						c.String("cluster-name"),
 						c.String("region"),
+						c.String("role-arn"),
+						c.String("session-name"),
 						c.Bool("no-cache"),
 						c.Bool("verbose"),
 					)
 					return gt.Execute(c.Context)
 				},
 			},
+			{
+				Name:  "whoami",
+				Usage: "Show the identity an EKS cluster sees for your token and whether it is mapped by an access entry",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.StringFlag{Name: "cluster-name", Aliases: []string{"c", "cluster"}, Usage: "The name of the EKS cluster", Required: true},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use, defaults to the region of the profile", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+					&cli.StringFlag{Name: "role-arn", Usage: "Role to assume with the credentials of the profile before generating the token"},
+					&cli.StringFlag{Name: "session-name", Usage: "Session name of the assumed role", Value: defaultSessionName},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					whoami := NewWhoAmI(
+						c.String("profile"),
+						c.String("cluster-name"),
+						c.String("region"),
+						c.String("role-arn"),
+						c.String("session-name"),
+						c.Bool("verbose"),
+					)
+					return whoami.Execute(c.Context)
+				},
+			},
+			{
+				Name:      "connect",
+				Usage:     "Open a shell or run a command with access to a private EKS cluster through an SSM port forward",
+				UsageText: "aws-fuzzy eks connect [command options] [-- command [arguments...]]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.StringFlag{Name: "cluster-name", Aliases: []string{"c"}, Usage: "The name of the EKS cluster, defaults to the one selected in the picker"},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use, defaults to the region of the profile", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+					&cli.StringFlag{Name: "role-arn", Usage: "Role to assume with the credentials of the profile before generating the token"},
+					&cli.StringFlag{Name: "session-name", Usage: "Session name of the assumed role", Value: defaultSessionName},
+					&cli.StringFlag{Name: "instance", Aliases: []string{"i"}, Usage: "Instance to forward the port through, defaults to an instance of the cluster VPC with the SSM agent online"},
+					&cli.IntFlag{Name: "port", Usage: "Local port to forward, defaults to a random free port"},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					connect := NewConnect(
+						c.String("profile"),
+						c.String("cluster-name"),
+						c.String("region"),
+						c.String("role-arn"),
+						c.String("session-name"),
+						c.String("instance"),
+						c.Int("port"),
+						connectArgs(c.Args().Slice()),
+						c.Bool("verbose"),
+					)
+					return connect.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "update-kubeconfig",
+				Usage: "Add contexts for the EKS clusters of your profiles to the kubeconfig",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{Name: "profiles", Usage: "What profiles to search for clusters, defaults to all profiles with cached credentials"},
+					&cli.StringSliceFlag{Name: "regions", Aliases: []string{"r"}, Usage: "What AWS regions to search for clusters, defaults to the region of each profile"},
+					&cli.StringFlag{Name: "kubeconfig", Usage: "What kubeconfig file to update, defaults to the first file of $KUBECONFIG or ~/.kube/config"},
+					&cli.BoolFlag{Name: "all", Aliases: []string{"a"}, Usage: "Add every cluster found instead of selecting one"},
+					&cli.BoolFlag{Name: "prune", Usage: "Remove contexts of clusters that no longer exist"},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					update := NewUpdateKubeconfig(
+						c.StringSlice("profiles"),
+						c.StringSlice("regions"),
+						c.String("kubeconfig"),
+						c.Bool("all"),
+						c.Bool("prune"),
+						c.Bool("verbose"),
+					)
+					return update.Execute(c.Context)
+				},
+			},
 		},
 	}
 
@@ -6,13 +6,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
 	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
 	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/smithy-go/middleware"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
@@ -24,9 +29,20 @@ const (
 	tokenTTL         = 15 * time.Minute
 	presignedURLTTL  = 60 // seconds, must be part of the signed URL for EKS to accept it
 	clusterIDHeader  = "x-k8s-aws-id"
+	// set by kubectl to the ExecCredential it expects, see the client-go credential plugins documentation
+	execInfoEnvVar = "KUBERNETES_EXEC_INFO"
+	// used with --role-arn when no session name is given
+	defaultSessionName = "aws-fuzzy"
 )
 
-// ExecCredential matches the Kubernetes client.authentication.k8s.io/v1beta1 ExecCredential format
+// execAPIVersions are the ExecCredential versions get-token can answer with
+var execAPIVersions = []string{
+	"client.authentication.k8s.io/v1",
+	"client.authentication.k8s.io/v1beta1",
+}
+
+// ExecCredential matches the Kubernetes client.authentication.k8s.io/v1 and v1beta1 ExecCredential format,
+// both versions have the same fields
 type ExecCredential struct {
 	Kind       string               `json:"kind"`
 	APIVersion string               `json:"apiVersion"`
@@ -41,11 +57,17 @@ type ExecCredentialStatus struct {
 	Token               string `json:"token"`
 }
 
-func NewGetToken(profile, clusterName, region string, noCache, verbose bool) *GetToken {
+func NewGetToken(profile, clusterName, region, roleArn, sessionName string, noCache, verbose bool) *GetToken {
+	if sessionName == "" {
+		sessionName = defaultSessionName
+	}
+
 	return &GetToken{
 		Profile:     profile,
 		ClusterName: clusterName,
 		Region:      region,
+		RoleArn:     roleArn,
+		SessionName: sessionName,
 		NoCache:     noCache,
 		Verbose:     verbose,
 	}
@@ -56,22 +78,76 @@ func (g *GetToken) Execute(ctx context.Context) error {
 		clio.SetLevelFromString("debug")
 	}
 
+	// fail before generating a token kubectl would not understand
+	apiVersion, err := requestedExecAPIVersion()
+	if err != nil {
+		return err
+	}
+
+	token, expiration, err := g.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	return g.outputExecCredential(apiVersion, token, expiration)
+}
+
+// requestedExecAPIVersion returns the ExecCredential version kubectl asked for in KUBERNETES_EXEC_INFO,
+// older clients do not set it and get v1beta1
+func requestedExecAPIVersion() (string, error) {
+	info := os.Getenv(execInfoEnvVar)
+	if info == "" {
+		return execAPIVersion, nil
+	}
+
+	var execInfo struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	err := json.Unmarshal([]byte(info), &execInfo)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", execInfoEnvVar, err)
+	}
+
+	for _, version := range execAPIVersions {
+		if execInfo.APIVersion == version {
+			return version, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported ExecCredential version %q, supported versions are %s", execInfo.APIVersion, strings.Join(execAPIVersions, ", "))
+}
+
+// cacheKey identifies the token of the cluster, tokens of different regions or roles are not interchangeable
+func (g *GetToken) cacheKey() string {
+	key := g.Profile + ":" + g.ClusterName
+	if g.Region != "" {
+		key += ":" + g.Region
+	}
+	if g.RoleArn != "" {
+		key += ":" + g.RoleArn + ":" + g.SessionName
+	}
+
+	return key
+}
+
+// token returns the cached token of the cluster or generates a new one
+func (g *GetToken) token(ctx context.Context) (string, time.Time, error) {
 	// Check cache first
-	cacheKey := g.Profile + ":" + g.ClusterName
+	cacheKey := g.cacheKey()
 	tokenStore := securestorage.NewSecureEKSTokenStorage()
 
 	if !g.NoCache {
-		cached, err := tokenStore.GetValidEKSToken(cacheKey)
+		cached, err := tokenStore.GetValidEKSToken(ctx, cacheKey)
 		if err == nil && cached != nil {
-			return g.outputExecCredential(cached.Token, cached.Expiration)
+			return cached.Token, cached.Expiration, nil
 		}
 	}
 
 	// Get AWS credentials using the existing SSO login flow
 	login := sso.Login{Profile: g.Profile}
-	creds, err := login.GetCredentials(ctx)
+	creds, err := login.GetCredentialsViaAgent(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get AWS credentials: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to get AWS credentials: %w", err)
 	}
 
 	// Build AWS config with credentials and optional region
@@ -81,22 +157,71 @@ func (g *GetToken) Execute(ctx context.Context) error {
 	}
 	cfg, err := sso.NewAwsConfig(ctx, creds, configOpts...)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS config: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to create AWS config: %w", err)
+	}
+
+	if g.RoleArn != "" {
+		cfg, err = g.assumeRole(ctx, cfg)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to assume role %s: %w", g.RoleArn, err)
+		}
 	}
 
 	// Generate the EKS token
 	token, expiration, err := g.generateToken(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to generate EKS token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to generate EKS token: %w", err)
 	}
 
 	// Cache the token
-	tokenStore.StoreEKSToken(cacheKey, securestorage.EKSToken{
+	tokenStore.StoreEKSToken(ctx, cacheKey, securestorage.EKSToken{
 		Token:      token,
 		Expiration: expiration,
 	})
 
-	return g.outputExecCredential(token, expiration)
+	return token, expiration, nil
+}
+
+// assumeRole returns a config with the credentials of the role, the token identifies the role instead of the profile
+func (g *GetToken) assumeRole(ctx context.Context, cfg aws.Config) (aws.Config, error) {
+	if cfg.Region == "" {
+		cfg.Region = partitionRegion(g.RoleArn)
+	}
+
+	out, err := sts.NewFromConfig(cfg).AssumeRole(ctx, &sts.AssumeRoleInput{
+		RoleArn:         aws.String(g.RoleArn),
+		RoleSessionName: aws.String(g.SessionName),
+	})
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	clio.Debugf("assumed role %s as %s", g.RoleArn, aws.ToString(out.AssumedRoleUser.Arn))
+
+	creds := awsprofile.TypeCredsToAwsCreds(*out.Credentials)
+	cfg.Credentials = credentials.StaticCredentialsProvider{Value: creds}
+
+	return cfg, nil
+}
+
+// partitionRegion returns a region of the partition of the ARN, STS is available in every region so only
+// the partition matters
+func partitionRegion(roleArn string) string {
+	regions := map[string]string{
+		"aws":        "us-east-1",
+		"aws-cn":     "cn-north-1",
+		"aws-us-gov": "us-gov-west-1",
+		"aws-iso":    "us-iso-east-1",
+		"aws-iso-b":  "us-isob-east-1",
+	}
+
+	if parsed, err := arn.Parse(roleArn); err == nil {
+		if region, ok := regions[parsed.Partition]; ok {
+			return region
+		}
+	}
+
+	return "us-east-1"
 }
 
 func (g *GetToken) generateToken(ctx context.Context, cfg aws.Config) (string, time.Time, error) {
@@ -128,10 +253,10 @@ func (g *GetToken) generateToken(ctx context.Context, cfg aws.Config) (string, t
 	return token, expiration, nil
 }
 
-func (g *GetToken) outputExecCredential(token string, expiration time.Time) error {
+func (g *GetToken) outputExecCredential(apiVersion, token string, expiration time.Time) error {
 	cred := ExecCredential{
 		Kind:       "ExecCredential",
-		APIVersion: "client.authentication.k8s.io/v1beta1",
+		APIVersion: apiVersion,
 		Spec:       ExecCredentialSpec{},
 		Status: ExecCredentialStatus{
 			ExpirationTimestamp: expiration.UTC().Format(time.RFC3339),
@@ -0,0 +1,63 @@
+package eks
+
+import (
+	"testing"
+)
+
+func TestRequestedExecAPIVersion(t *testing.T) {
+	tests := map[string]string{
+		"": "client.authentication.k8s.io/v1beta1",
+		`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","spec":{"interactive":true}}`:       "client.authentication.k8s.io/v1",
+		`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false}}`: "client.authentication.k8s.io/v1beta1",
+	}
+
+	for info, want := range tests {
+		t.Setenv(execInfoEnvVar, info)
+
+		got, err := requestedExecAPIVersion()
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", info, err)
+		}
+		if got != want {
+			t.Errorf("got %s for %q, want %s", got, info, want)
+		}
+	}
+
+	t.Setenv(execInfoEnvVar, `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1alpha1"}`)
+	if _, err := requestedExecAPIVersion(); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		token *GetToken
+		want  string
+	}{
+		{NewGetToken("dev", "main", "", "", "", false, false), "dev:main"},
+		{NewGetToken("dev", "main", "us-east-1", "", "", false, false), "dev:main:us-east-1"},
+		{NewGetToken("dev", "main", "us-east-1", "arn:aws:iam::123456789012:role/admin", "", false, false), "dev:main:us-east-1:arn:aws:iam::123456789012:role/admin:aws-fuzzy"},
+		{NewGetToken("dev", "main", "us-east-1", "arn:aws:iam::123456789012:role/admin", "me", false, false), "dev:main:us-east-1:arn:aws:iam::123456789012:role/admin:me"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.token.cacheKey(); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestPartitionRegion(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:iam::123456789012:role/admin":        "us-east-1",
+		"arn:aws-cn:iam::123456789012:role/admin":     "cn-north-1",
+		"arn:aws-us-gov:iam::123456789012:role/admin": "us-gov-west-1",
+		"not-an-arn": "us-east-1",
+	}
+
+	for roleArn, want := range tests {
+		if got := partitionRegion(roleArn); got != want {
+			t.Errorf("partitionRegion(%s) = %s, want %s", roleArn, got, want)
+		}
+	}
+}
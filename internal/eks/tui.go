@@ -0,0 +1,145 @@
+package eks
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/AndreZiviani/fzf-wrapper/v2"
+	"github.com/rivo/tview"
+)
+
+// Resource is anything that can be listed and selected in the TUI
+type Resource interface {
+	PrintName() string
+	PrintDetails() string
+}
+
+func (c Cluster) PrintName() string {
+	return fmt.Sprintf("%s/%s (%s)", c.Profile, c.Name, c.Region)
+}
+
+func (c Cluster) PrintDetails() string {
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Name: %s\n", c.Name)
+	fmt.Fprintf(output, "Profile: %s\n", c.Profile)
+	fmt.Fprintf(output, "Region: %s\n", c.Region)
+	fmt.Fprintf(output, "Arn: %s\n", c.Arn)
+	fmt.Fprintf(output, "Status: %s\n", c.Status)
+	fmt.Fprintf(output, "Version: %s\n", c.Version)
+	fmt.Fprintf(output, "Endpoint: %s\n", c.Endpoint)
+	fmt.Fprintf(output, "Vpc: %s\n", c.VpcID)
+
+	return output.String()
+}
+
+type Tui struct {
+	app             *tview.Application
+	flex            *tview.Flex
+	input           *tview.InputField
+	resourceList    *tview.List
+	resourceDetails *tview.TextView
+	fzf             *fzfwrapper.Wrapper
+	resources       []Resource
+	selected        int
+	resourceIdx     []int
+}
+
+type FzfData struct {
+	Resources []Resource
+}
+
+func (f FzfData) FzfInputList() []string {
+	out := make([]string, 0, f.FzfInputLen())
+
+	for _, r := range f.Resources {
+		out = append(out, r.PrintDetails())
+	}
+
+	return out
+}
+
+func (f FzfData) FzfInputLen() int {
+	return len(f.Resources)
+}
+
+func (t *Tui) resourceListFunc(id int, text string, secondary string, shortcut rune) {
+	t.resourceDetails.SetText(
+		fmt.Sprintf("%s\n", secondary),
+	)
+}
+
+func (t *Tui) inputFunc(text string) {
+	if text == "" {
+		t.resourceList.Clear()
+		last := len(t.resources) - 1
+		for k, v := range t.resources {
+			t.resourceIdx[last-k] = k
+			t.resourceList.InsertItem(
+				-t.resourceList.GetItemCount()-1,
+				v.PrintName(),
+				v.PrintDetails(),
+				0, nil,
+			)
+		}
+		return
+	}
+
+	t.fzf.SetPattern(text)
+	results, _ := t.fzf.Fuzzy()
+
+	t.resourceList.Clear()
+	t.resourceDetails.Clear()
+
+	last := len(results) - 1
+	for k, v := range results {
+		t.resourceIdx[last-k] = int(v.Item.Index())
+		r := t.resources[v.Item.Index()]
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			tview.TranslateANSI(
+				r.PrintName(),
+			),
+			tview.TranslateANSI(v.HighlightResult()),
+			0, nil,
+		)
+	}
+
+	t.resourceList.SetCurrentItem(-1)
+	t.resourceList.SetOffset(0, 0)
+	boldItem(t.resourceList, t.resourceList.GetCurrentItem())
+}
+
+// tui returns the index of the selected resource
+func tui(title string, resources []Resource) (int, error) {
+
+	t := NewTui()
+	t.resourceList.SetTitle(title)
+
+	t.fzf.SetInput(FzfData{Resources: resources})
+	t.resources = resources
+	t.resourceIdx = make([]int, len(t.resources))
+
+	last := len(t.resources) - 1
+
+	for k, v := range t.resources {
+		t.resourceIdx[last-k] = k // reverse order since we are adding items to the beggining of the list
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			v.PrintName(),
+			v.PrintDetails(),
+			0, nil,
+		)
+	}
+
+	if err := t.app.SetRoot(t.flex, true).SetFocus(t.flex).Run(); err != nil {
+		panic(err)
+	}
+
+	if t.selected < 0 {
+		// user aborted the selection (ctrl+c?)
+		return -1, fmt.Errorf("aborting by user request")
+	}
+
+	return t.selected, nil
+}
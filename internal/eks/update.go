@@ -0,0 +1,108 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewUpdateKubeconfig(profiles, regions []string, kubeconfig string, all, prune, verbose bool) *UpdateKubeconfig {
+	return &UpdateKubeconfig{
+		Profiles:   profiles,
+		Regions:    regions,
+		Kubeconfig: kubeconfig,
+		All:        all,
+		Prune:      prune,
+		Verbose:    verbose,
+	}
+}
+
+func (p *UpdateKubeconfig) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "eksupdatekubeconfig")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	path, err := kubeconfigPath(p.Kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	clusters, searched, err := discoverClusters(ctx, p.Profiles, p.Regions)
+	if err != nil {
+		return err
+	}
+
+	if len(clusters) == 0 && !p.Prune {
+		return fmt.Errorf("no EKS clusters found")
+	}
+
+	// names are chosen with every cluster found so they do not change with the selection
+	names := contextNames(clusters)
+
+	selected := make([]int, 0, len(clusters))
+	if p.All {
+		for i := range clusters {
+			selected = append(selected, i)
+		}
+	} else if len(clusters) > 0 {
+		resources := make([]Resource, 0, len(clusters))
+		for _, c := range clusters {
+			resources = append(resources, c)
+		}
+
+		idx, err := choose("Clusters", resources)
+		if err != nil {
+			return err
+		}
+		selected = append(selected, idx)
+	}
+
+	k, err := loadKubeconfig(path)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range selected {
+		if k.upsert(names[i], clusters[i]) {
+			clio.Successf("Added context %s", names[i])
+		} else {
+			clio.Infof("Updated context %s", names[i])
+		}
+	}
+
+	if p.Prune {
+		for _, name := range k.prune(clusters, searched) {
+			clio.Infof("Removed context %s, its cluster no longer exists", name)
+		}
+	}
+
+	// a single cluster was picked so it is probably the one the user wants to use next
+	if !p.All && len(selected) == 1 {
+		k.CurrentContext = names[selected[0]]
+		clio.Infof("Switched to context %s", k.CurrentContext)
+	}
+
+	return k.save(path)
+}
+
+// choose only prompts the user when there is more than one option
+func choose(title string, resources []Resource) (int, error) {
+	if len(resources) == 1 {
+		return 0, nil
+	}
+
+	return tui(title, resources)
+}
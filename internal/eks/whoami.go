@@ -0,0 +1,308 @@
+package eks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awseks "github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// accessEntry is an access entry of the cluster with its associated access policies
+type accessEntry struct {
+	PrincipalArn string
+	Policies     []ekstypes.AssociatedAccessPolicy
+}
+
+func NewWhoAmI(profile, clusterName, region, roleArn, sessionName string, verbose bool) *WhoAmI {
+	return &WhoAmI{
+		Profile:     profile,
+		ClusterName: clusterName,
+		Region:      region,
+		RoleArn:     roleArn,
+		SessionName: sessionName,
+		Verbose:     verbose,
+	}
+}
+
+func (p *WhoAmI) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ekswhoami")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	region, err := resolveRegion(ctx, p.Profile, p.Region)
+	if err != nil {
+		return err
+	}
+
+	// the same token kubectl gets from get-token, including a cached one
+	gt := NewGetToken(p.Profile, p.ClusterName, region, p.RoleArn, p.SessionName, false, p.Verbose)
+	token, _, err := gt.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	caller, err := tokenCaller(ctx, token, p.ClusterName)
+	if err != nil {
+		return err
+	}
+	principal := principalARN(caller)
+
+	login := sso.Login{Profile: p.Profile}
+	creds, err := login.GetCredentialsViaAgent(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(region))
+	if err != nil {
+		return err
+	}
+
+	client := awseks.NewFromConfig(cfg)
+
+	cluster, err := client.DescribeCluster(ctx, &awseks.DescribeClusterInput{Name: aws.String(p.ClusterName)})
+	if err != nil {
+		return err
+	}
+
+	// clusters created before access entries existed have no access config and only use the ConfigMap
+	mode := ekstypes.AuthenticationModeConfigMap
+	if cluster.Cluster.AccessConfig != nil && cluster.Cluster.AccessConfig.AuthenticationMode != "" {
+		mode = cluster.Cluster.AccessConfig.AuthenticationMode
+	}
+
+	fmt.Printf("Cluster: %s (%s)\n", p.ClusterName, region)
+	fmt.Printf("Authentication mode: %s\n", mode)
+	fmt.Printf("Caller: %s\n", caller)
+	fmt.Printf("Principal: %s\n", principal)
+
+	if mode == ekstypes.AuthenticationModeConfigMap {
+		clio.Infof("The cluster only uses the aws-auth ConfigMap, %s must be in the mapRoles or mapUsers of kube-system/aws-auth", principal)
+		return nil
+	}
+
+	entries, err := listAccessEntries(ctx, client, p.ClusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list the access entries of %s, %w", p.ClusterName, err)
+	}
+
+	matched := printAccessEntries(entries, principal)
+
+	if matched == nil {
+		if mode == ekstypes.AuthenticationModeApiAndConfigMap {
+			clio.Warnf("%s has no access entry, it can only be mapped by the aws-auth ConfigMap in kube-system", principal)
+		} else {
+			clio.Warnf("%s has no access entry, kubectl will return Unauthorized", principal)
+		}
+		return nil
+	}
+
+	out, err := client.DescribeAccessEntry(ctx, &awseks.DescribeAccessEntryInput{
+		ClusterName:  aws.String(p.ClusterName),
+		PrincipalArn: aws.String(matched.PrincipalArn),
+	})
+	if err != nil {
+		return err
+	}
+
+	entry := out.AccessEntry
+	clio.Successf("%s is mapped by the access entry of %s, type %s, Kubernetes username %s, groups [%s]",
+		principal, matched.PrincipalArn, aws.ToString(entry.Type), aws.ToString(entry.Username), strings.Join(entry.KubernetesGroups, ", "))
+
+	if len(matched.Policies) == 0 && len(entry.KubernetesGroups) == 0 {
+		clio.Warnf("The access entry has no access policies or Kubernetes groups, only RBAC bindings of username %s grant permissions", aws.ToString(entry.Username))
+	}
+
+	return nil
+}
+
+// tokenCaller calls STS with the presigned request of the token, like the EKS authenticator does,
+// and returns the ARN of the caller
+func tokenCaller(ctx context.Context, token, clusterName string) (string, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return "", fmt.Errorf("invalid token, missing prefix %s", tokenPrefix)
+	}
+
+	presigned, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, tokenPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode token, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, string(presigned), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(clusterIDHeader, clusterName)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("STS rejected the token with status %d: %s", resp.StatusCode, body)
+	}
+
+	var identity struct {
+		GetCallerIdentityResponse struct {
+			GetCallerIdentityResult struct {
+				Arn string
+			}
+		}
+	}
+	err = json.Unmarshal(body, &identity)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the STS response, %w", err)
+	}
+
+	return identity.GetCallerIdentityResponse.GetCallerIdentityResult.Arn, nil
+}
+
+// principalARN returns the IAM ARN of the caller as seen by the cluster, the role of an assumed role session
+func principalARN(caller string) string {
+	parsed, err := arn.Parse(caller)
+	if err != nil || parsed.Service != "sts" || !strings.HasPrefix(parsed.Resource, "assumed-role/") {
+		return caller
+	}
+
+	parts := strings.Split(parsed.Resource, "/")
+	parsed.Service = "iam"
+	parsed.Resource = "role/" + parts[1]
+
+	return parsed.String()
+}
+
+// withoutPath removes the path of a role ARN, the ARN of an assumed role session does not include it
+// so the cluster matches access entries without it, e.g. the roles of IAM Identity Center
+func withoutPath(principal string) string {
+	parsed, err := arn.Parse(principal)
+	if err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return principal
+	}
+
+	parts := strings.Split(parsed.Resource, "/")
+	parsed.Resource = "role/" + parts[len(parts)-1]
+
+	return parsed.String()
+}
+
+// matchAccessEntry returns the access entry of the principal
+func matchAccessEntry(entries []accessEntry, principal string) *accessEntry {
+	for i := range entries {
+		if withoutPath(entries[i].PrincipalArn) == withoutPath(principal) {
+			return &entries[i]
+		}
+	}
+
+	return nil
+}
+
+func listAccessEntries(ctx context.Context, client *awseks.Client, clusterName string) ([]accessEntry, error) {
+	entries := []accessEntry{}
+
+	paginator := awseks.NewListAccessEntriesPaginator(client, &awseks.ListAccessEntriesInput{ClusterName: aws.String(clusterName)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, principal := range page.AccessEntries {
+			entry := accessEntry{PrincipalArn: principal}
+
+			policies := awseks.NewListAssociatedAccessPoliciesPaginator(client, &awseks.ListAssociatedAccessPoliciesInput{
+				ClusterName:  aws.String(clusterName),
+				PrincipalArn: aws.String(principal),
+			})
+			for policies.HasMorePages() {
+				out, err := policies.NextPage(ctx)
+				if err != nil {
+					return nil, err
+				}
+				entry.Policies = append(entry.Policies, out.AssociatedAccessPolicies...)
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// printAccessEntries prints one line for each access policy of each entry, the entry of the principal
+// is marked with an asterisk and returned
+func printAccessEntries(entries []accessEntry, principal string) *accessEntry {
+	matched := matchAccessEntry(entries, principal)
+
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPRINCIPAL\tPOLICY\tSCOPE")
+
+	for i, entry := range entries {
+		mark := ""
+		if matched == &entries[i] {
+			mark = "*"
+		}
+
+		if len(entry.Policies) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, entry.PrincipalArn, "-", "-")
+			continue
+		}
+
+		for _, policy := range entry.Policies {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, entry.PrincipalArn, policyName(policy), policyScope(policy))
+		}
+	}
+
+	_ = w.Flush()
+	fmt.Println()
+
+	return matched
+}
+
+// policyName returns the name of an access policy, the last part of its ARN
+func policyName(policy ekstypes.AssociatedAccessPolicy) string {
+	parts := strings.Split(aws.ToString(policy.PolicyArn), "/")
+	return parts[len(parts)-1]
+}
+
+func policyScope(policy ekstypes.AssociatedAccessPolicy) string {
+	if policy.AccessScope == nil || policy.AccessScope.Type == ekstypes.AccessScopeTypeCluster {
+		return "cluster"
+	}
+
+	return "namespaces " + strings.Join(policy.AccessScope.Namespaces, ",")
+}
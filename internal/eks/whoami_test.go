@@ -0,0 +1,69 @@
+package eks
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrincipalARN(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_0123/user@example.com": "arn:aws:iam::123456789012:role/AWSReservedSSO_Admin_0123",
+		"arn:aws-cn:sts::123456789012:assumed-role/deploy/session":                          "arn:aws-cn:iam::123456789012:role/deploy",
+		"arn:aws:iam::123456789012:user/alice":                                              "arn:aws:iam::123456789012:user/alice",
+	}
+
+	for caller, want := range tests {
+		if got := principalARN(caller); got != want {
+			t.Errorf("principalARN(%s) = %s, want %s", caller, got, want)
+		}
+	}
+}
+
+func TestMatchAccessEntry(t *testing.T) {
+	entries := []accessEntry{
+		{PrincipalArn: "arn:aws:iam::123456789012:role/ci"},
+		{PrincipalArn: "arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_0123"},
+	}
+
+	matched := matchAccessEntry(entries, "arn:aws:iam::123456789012:role/AWSReservedSSO_Admin_0123")
+	if matched != &entries[1] {
+		t.Errorf("expected the entry of the role with a path to match, got %v", matched)
+	}
+
+	if matched := matchAccessEntry(entries, "arn:aws:iam::123456789012:role/other"); matched != nil {
+		t.Errorf("expected no match, got %v", matched)
+	}
+}
+
+func TestTokenCaller(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(clusterIDHeader) != "my-cluster" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"Error":{"Code":"SignatureDoesNotMatch"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"GetCallerIdentityResponse":{"GetCallerIdentityResult":{"Arn":"arn:aws:sts::123456789012:assumed-role/dev/me","Account":"123456789012"}}}`))
+	}))
+	defer server.Close()
+
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(server.URL+"/?Action=GetCallerIdentity"))
+
+	caller, err := tokenCaller(context.Background(), token, "my-cluster")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caller != "arn:aws:sts::123456789012:assumed-role/dev/me" {
+		t.Errorf("got %s", caller)
+	}
+
+	if _, err := tokenCaller(context.Background(), token, "other-cluster"); err == nil {
+		t.Error("expected an error when STS rejects the token")
+	}
+
+	if _, err := tokenCaller(context.Background(), "not-a-token", "my-cluster"); err == nil {
+		t.Error("expected an error for a token without prefix")
+	}
+}
@@ -0,0 +1,204 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"gopkg.in/ini.v1"
+)
+
+// environment variables that must not leak from the parent into the child process,
+// otherwise they could take precedence over the credentials we are injecting
+var strippedEnv = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_SECURITY_TOKEN",
+	"AWS_CREDENTIAL_EXPIRATION",
+	"AWS_PROFILE",
+	"AWS_DEFAULT_PROFILE",
+	"AWS_CONFIG_FILE",
+	"AWS_SHARED_CREDENTIALS_FILE",
+}
+
+func New(profile string, command []string, refresh, verbose bool) *Exec {
+	exec := Exec{
+		Profile: profile,
+		Command: command,
+		Refresh: refresh,
+		Verbose: verbose,
+	}
+
+	return &exec
+}
+
+func (p *Exec) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "exec")
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	login := sso.Login{Profile: p.Profile}
+
+	creds, err := login.GetCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	// region is optional, the child process may provide its own
+	region := ""
+	profile, err := login.GetProfile(p.Profile)
+	if err == nil {
+		region, _ = profile.Region(ctx)
+	}
+
+	span.Finish()
+
+	env := filterEnv(os.Environ())
+	if region != "" {
+		env = append(env, "AWS_REGION="+region, "AWS_DEFAULT_REGION="+region)
+	}
+
+	if p.Refresh {
+		dir, err := os.MkdirTemp("", "aws-fuzzy-exec-")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+
+		configPath := filepath.Join(dir, "config")
+		credsPath := filepath.Join(dir, "credentials")
+
+		err = writeConfigFile(configPath, p.Profile, region)
+		if err != nil {
+			return err
+		}
+
+		// keys of the profile in the credentials file of the user would take precedence over the credential process
+		err = ini.Empty().SaveTo(credsPath)
+		if err != nil {
+			return err
+		}
+
+		env = append(env,
+			"AWS_CONFIG_FILE="+configPath,
+			"AWS_SHARED_CREDENTIALS_FILE="+credsPath,
+			"AWS_PROFILE="+p.Profile,
+			sso.ExecConfigFileEnv+"="+os.Getenv("AWS_CONFIG_FILE"),
+			sso.ExecCredentialsFileEnv+"="+os.Getenv("AWS_SHARED_CREDENTIALS_FILE"),
+		)
+	} else {
+		env = append(env, credentialsEnv(creds)...)
+	}
+
+	return Run(p.Command, env)
+}
+
+// Run runs the command with the environment and waits for it to exit, ctrl+c is left to the command
+func Run(command []string, env []string) error {
+	cmd := osexec.Command(command[0], command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// the child process is in the same process group and receives ctrl+c directly from the terminal,
+	// ignore it here so we keep running until the child exits and forward termination requests
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for sig := range sigs {
+			if sig == os.Interrupt {
+				continue
+			}
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+func credentialsEnv(creds *aws.Credentials) []string {
+	env := []string{
+		"AWS_ACCESS_KEY_ID=" + creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + creds.SecretAccessKey,
+	}
+
+	if creds.SessionToken != "" {
+		env = append(env,
+			"AWS_SESSION_TOKEN="+creds.SessionToken,
+			"AWS_SECURITY_TOKEN="+creds.SessionToken,
+		)
+	}
+
+	if creds.CanExpire {
+		env = append(env, "AWS_CREDENTIAL_EXPIRATION="+creds.Expires.UTC().Format(time.RFC3339))
+	}
+
+	return env
+}
+
+func filterEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+
+	for _, e := range environ {
+		name, _, _ := strings.Cut(e, "=")
+
+		stripped := false
+		for _, s := range strippedEnv {
+			if name == s {
+				stripped = true
+				break
+			}
+		}
+
+		if !stripped {
+			env = append(env, e)
+		}
+	}
+
+	return env
+}
+
+// writeConfigFile writes a config with a single profile that gets its credentials from the credential process,
+// SDKs run it again when the credentials are about to expire
+func writeConfigFile(path, profile, region string) error {
+	c := ini.Empty()
+	afcfg := afconfig.NewDefaultConfig()
+
+	s := c.Section("profile " + profile)
+	if region != "" {
+		s.Key("region").SetValue(region)
+	}
+	s.Key("credential_process").SetValue(fmt.Sprintf("%s sso credential-process --profile %s", afcfg.AppName, profile))
+
+	return c.SaveTo(path)
+}
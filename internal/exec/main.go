@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	osexec "os/exec"
+
+	"github.com/urfave/cli/v2"
+)
+
+type Exec struct {
+	Profile string
+	Command []string
+	Refresh bool
+	Verbose bool
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:      "exec",
+		Usage:     "Run a command with AWS credentials",
+		UsageText: "aws-fuzzy exec [command options] -- <command> [arguments...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+			&cli.BoolFlag{Name: "refresh", Aliases: []string{"r"}, Usage: "Provide credentials through a temporary config that runs the credential process, SDKs fetch new credentials when they expire, for long running commands"},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("missing command to run, e.g. 'aws-fuzzy exec -p my-profile -- aws sts get-caller-identity'")
+			}
+
+			exec := New(c.String("profile"),
+				c.Args().Slice(),
+				c.Bool("refresh"),
+				c.Bool("verbose"),
+			)
+
+			err := exec.Execute(c.Context)
+
+			// exit with the same code as the child process
+			var exitErr *osexec.ExitError
+			if errors.As(err, &exitErr) {
+				return cli.Exit("", exitErr.ExitCode())
+			}
+
+			return err
+		},
+	}
+
+	return &command
+}
@@ -0,0 +1,90 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"gopkg.in/ini.v1"
+)
+
+// IAM is a global service, STS is called in this region when the profile does not set one
+const defaultRegion = "us-east-1"
+
+// configSection returns the name of the section of the profile in the AWS config file
+func configSection(profile string) string {
+	if profile == "default" {
+		return profile
+	}
+
+	return "profile " + profile
+}
+
+// profileRegion returns the region of the profile in the AWS config file or the default region
+func profileRegion(configFile *ini.File, profile string) string {
+	section, err := configFile.GetSection(configSection(profile))
+	if err == nil {
+		if key, err := section.GetKey("region"); err == nil && key.String() != "" {
+			return key.String()
+		}
+	}
+
+	return defaultRegion
+}
+
+// newConfig returns an AWS config that uses the given access key
+func newConfig(ctx context.Context, creds aws.Credentials, region string) (aws.Config, error) {
+	return sso.NewAwsConfig(ctx, &creds, config.WithRegion(region))
+}
+
+// verify returns the identity of the access key
+func verify(ctx context.Context, cfg aws.Config) (*sts.GetCallerIdentityOutput, error) {
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the access key, %w", err)
+	}
+
+	return identity, nil
+}
+
+// loadIni loads an AWS config or credentials file, a missing file is loaded as an empty one
+func loadIni(path string) (*ini.File, error) {
+	return ini.LoadSources(ini.LoadOptions{Loose: true}, path)
+}
+
+// saveIni replaces the file without aligning the keys, that would reformat sections that we did not touch
+func saveIni(c *ini.File, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	prettyFormat, prettyEqual := ini.PrettyFormat, ini.PrettyEqual
+	ini.PrettyFormat, ini.PrettyEqual = false, true
+	defer func() { ini.PrettyFormat, ini.PrettyEqual = prettyFormat, prettyEqual }()
+
+	// write to a temporary file first so readers never see a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = c.WriteTo(tmp)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,168 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"gopkg.in/ini.v1"
+)
+
+// keys of the access key in the AWS config and credentials files
+var accessKeyKeys = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"}
+
+func NewImport(profile string, verbose bool) *Import {
+	imp := Import{
+		Profile: profile,
+		Verbose: verbose,
+	}
+
+	return &imp
+}
+
+func (p *Import) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "iamimport")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	credsPath := config.DefaultSharedCredentialsFilename()
+	configPath := config.DefaultSharedConfigFilename()
+
+	credsFile, err := loadIni(credsPath)
+	if err != nil {
+		return err
+	}
+
+	configFile, err := loadIni(configPath)
+	if err != nil {
+		return err
+	}
+
+	// the access key can be in either file, the credentials file takes precedence like in the AWS SDK
+	creds, found, err := accessKey(credsFile, p.Profile)
+	if err == nil && !found {
+		creds, found, err = accessKey(configFile, configSection(p.Profile))
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no access key found for profile %s in %s or %s", p.Profile, credsPath, configPath)
+	}
+
+	cfg, err := newConfig(ctx, creds, profileRegion(configFile, p.Profile))
+	if err != nil {
+		return err
+	}
+
+	identity, err := verify(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	clio.Debugf("access key %s belongs to %s", creds.AccessKeyID, aws.ToString(identity.Arn))
+
+	store := securestorage.NewSecureIAMCredentialStorage()
+	err = store.StoreCredentials(ctx, p.Profile, creds)
+	if err != nil {
+		return fmt.Errorf("failed to store the access key in the keyring, %w", err)
+	}
+
+	err = p.rewriteFiles(credsFile, credsPath, configFile, configPath)
+	if err != nil {
+		return err
+	}
+
+	// a copy of the access key is cached along with the role credentials
+	roleStore := securestorage.NewSecureRoleCredentialsStorage()
+	roleStore.ClearCredentials(ctx, p.Profile)
+
+	clio.Successf("Moved access key %s of %s to the keyring, profile %s now uses credential_process", creds.AccessKeyID, aws.ToString(identity.Arn), p.Profile)
+
+	return nil
+}
+
+// rewriteFiles removes the access key from both files and sets the credential_process of the profile
+func (p *Import) rewriteFiles(credsFile *ini.File, credsPath string, configFile *ini.File, configPath string) error {
+	if section, err := credsFile.GetSection(p.Profile); err == nil {
+		for _, key := range accessKeyKeys {
+			section.DeleteKey(key)
+		}
+		if len(section.Keys()) == 0 {
+			credsFile.DeleteSection(p.Profile)
+		}
+
+		// the credentials file is not backed up, that would leave a copy of the access key behind
+		err = saveIni(credsFile, credsPath)
+		if err != nil {
+			return fmt.Errorf("failed to remove the access key from %s, %w", credsPath, err)
+		}
+	}
+
+	if _, err := os.Stat(configPath); err == nil {
+		err = sso.CopyFile(configPath, fmt.Sprintf("%s.bkp", configPath))
+		if err != nil {
+			return fmt.Errorf("could not backup config, %w", err)
+		}
+	}
+
+	afcfg := afconfig.NewDefaultConfig()
+
+	section := configFile.Section(configSection(p.Profile))
+	for _, key := range accessKeyKeys {
+		section.DeleteKey(key)
+	}
+	section.Key("credential_process").SetValue(fmt.Sprintf("%s sso credential-process --profile %s", afcfg.AppName, p.Profile))
+
+	err := saveIni(configFile, configPath)
+	if err != nil {
+		return fmt.Errorf("failed to update %s, %w", configPath, err)
+	}
+
+	return nil
+}
+
+// accessKey returns the long-lived access key of a section, temporary credentials can not be imported
+func accessKey(file *ini.File, name string) (aws.Credentials, bool, error) {
+	section, err := file.GetSection(name)
+	if err != nil {
+		return aws.Credentials{}, false, nil
+	}
+
+	id, err := section.GetKey("aws_access_key_id")
+	if err != nil || id.String() == "" {
+		return aws.Credentials{}, false, nil
+	}
+
+	secret, err := section.GetKey("aws_secret_access_key")
+	if err != nil || secret.String() == "" {
+		return aws.Credentials{}, false, fmt.Errorf("section %s has an access key id but no secret access key", name)
+	}
+
+	if token, err := section.GetKey("aws_session_token"); err == nil && token.String() != "" {
+		return aws.Credentials{}, false, errors.New("the profile has temporary credentials, only the access keys of IAM users can be imported")
+	}
+
+	return aws.Credentials{
+		AccessKeyID:     id.String(),
+		SecretAccessKey: secret.String(),
+	}, true, nil
+}
@@ -0,0 +1,96 @@
+package iam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestAccessKey(t *testing.T) {
+	file, err := ini.Load([]byte(`
+[dev]
+aws_access_key_id = AKIAEXAMPLE
+aws_secret_access_key = secret
+
+[temporary]
+aws_access_key_id = ASIAEXAMPLE
+aws_secret_access_key = secret
+aws_session_token = token
+
+[incomplete]
+aws_access_key_id = AKIAEXAMPLE
+
+[region-only]
+region = eu-west-1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, found, err := accessKey(file, "dev")
+	if err != nil || !found {
+		t.Fatalf("expected an access key, got found=%v err=%v", found, err)
+	}
+	if creds.AccessKeyID != "AKIAEXAMPLE" || creds.SecretAccessKey != "secret" {
+		t.Errorf("unexpected access key %+v", creds)
+	}
+
+	if _, _, err := accessKey(file, "temporary"); err == nil {
+		t.Error("expected an error for temporary credentials")
+	}
+
+	if _, _, err := accessKey(file, "incomplete"); err == nil {
+		t.Error("expected an error for an access key without secret")
+	}
+
+	for _, name := range []string{"region-only", "missing"} {
+		if _, found, err := accessKey(file, name); found || err != nil {
+			t.Errorf("%s: expected no access key, got found=%v err=%v", name, found, err)
+		}
+	}
+}
+
+func TestRewriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "credentials")
+	configPath := filepath.Join(dir, "config")
+
+	err := os.WriteFile(credsPath, []byte("[dev]\naws_access_key_id = AKIAEXAMPLE\naws_secret_access_key = secret\n\n[other]\naws_access_key_id = AKIAOTHER\naws_secret_access_key = other\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(configPath, []byte("# my profiles\n[profile dev]\nregion = eu-west-1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credsFile, _ := loadIni(credsPath)
+	configFile, _ := loadIni(configPath)
+
+	imp := NewImport("dev", false)
+	if err := imp.rewriteFiles(credsFile, credsPath, configFile, configPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	creds, _ := os.ReadFile(credsPath)
+	if strings.Contains(string(creds), "AKIAEXAMPLE") || strings.Contains(string(creds), "[dev]") {
+		t.Errorf("the access key was not removed:\n%s", creds)
+	}
+	if !strings.Contains(string(creds), "AKIAOTHER") {
+		t.Errorf("other profiles were removed:\n%s", creds)
+	}
+
+	config, _ := os.ReadFile(configPath)
+	for _, want := range []string{"# my profiles", "region=eu-west-1", "credential_process=aws-fuzzy sso credential-process --profile dev"} {
+		if !strings.Contains(strings.ReplaceAll(string(config), " = ", "="), want) {
+			t.Errorf("expected %q in the config file:\n%s", want, config)
+		}
+	}
+
+	if _, err := os.Stat(configPath + ".bkp"); err != nil {
+		t.Errorf("expected a backup of the config file, %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package iam
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+type Import struct {
+	Profile string
+	Verbose bool
+}
+
+type Rotate struct {
+	Profile string
+	Verbose bool
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:  "iam",
+		Usage: "Manage the long-lived access keys of IAM users",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "import",
+				Usage: "Move the access keys of a profile from the AWS credentials file to the keyring",
+				Description: "The access keys are verified with STS, stored in the keyring and removed from the AWS credentials file.\n" +
+					"The profile is changed to get them with 'aws-fuzzy sso credential-process'.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					imp := NewImport(c.String("profile"), c.Bool("verbose"))
+
+					return imp.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "rotate",
+				Usage: "Replace the access key of a profile stored in the keyring with a new one",
+				Description: "A new access key is created and stored in the keyring, the old one is deleted once the new one is verified with STS.\n" +
+					"The IAM user can have at most two access keys, the unused one must be deleted first.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					rotate := NewRotate(c.String("profile"), c.Bool("verbose"))
+
+					return rotate.Execute(c.Context)
+				},
+			},
+		},
+	}
+
+	return &command
+}
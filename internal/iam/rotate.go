@@ -0,0 +1,170 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awsiam "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// an IAM user can have at most two access keys
+	maxAccessKeys = 2
+
+	// new access keys take a few seconds to be accepted by STS
+	verifyAttempts = 10
+	verifyInterval = 3 * time.Second
+)
+
+func NewRotate(profile string, verbose bool) *Rotate {
+	rotate := Rotate{
+		Profile: profile,
+		Verbose: verbose,
+	}
+
+	return &rotate
+}
+
+func (p *Rotate) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "iamrotate")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	store := securestorage.NewSecureIAMCredentialStorage()
+	oldCreds, err := store.GetCredentials(ctx, p.Profile)
+	if errors.Is(err, securestorage.ErrNotFound) {
+		return fmt.Errorf("the access key of profile %s is not in the keyring, run 'aws-fuzzy iam import --profile %s' first", p.Profile, p.Profile)
+	}
+	if err != nil {
+		return err
+	}
+
+	configFile, err := loadIni(config.DefaultSharedConfigFilename())
+	if err != nil {
+		return err
+	}
+	region := profileRegion(configFile, p.Profile)
+
+	oldCfg, err := newConfig(ctx, oldCreds, region)
+	if err != nil {
+		return err
+	}
+	oldClient := awsiam.NewFromConfig(oldCfg)
+
+	// without a user name the access keys of the user of the current access key are used
+	keys, err := oldClient.ListAccessKeys(ctx, &awsiam.ListAccessKeysInput{})
+	if err != nil {
+		return fmt.Errorf("failed to list the access keys, %w", err)
+	}
+	if len(keys.AccessKeyMetadata) >= maxAccessKeys {
+		return fmt.Errorf("the IAM user already has %d access keys, delete the one that is not %s first", len(keys.AccessKeyMetadata), oldCreds.AccessKeyID)
+	}
+
+	out, err := oldClient.CreateAccessKey(ctx, &awsiam.CreateAccessKeyInput{})
+	if err != nil {
+		return fmt.Errorf("failed to create a new access key, %w", err)
+	}
+
+	newCreds := aws.Credentials{
+		AccessKeyID:     aws.ToString(out.AccessKey.AccessKeyId),
+		SecretAccessKey: aws.ToString(out.AccessKey.SecretAccessKey),
+	}
+	clio.Debugf("created access key %s", newCreds.AccessKeyID)
+
+	err = store.StoreCredentials(ctx, p.Profile, newCreds)
+	if err == nil {
+		err = p.verify(ctx, newCreds, region)
+	}
+	if err != nil {
+		// keep using the old access key
+		p.rollback(ctx, store, oldClient, oldCreds, newCreds.AccessKeyID)
+		return err
+	}
+
+	newCfg, err := newConfig(ctx, newCreds, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = awsiam.NewFromConfig(newCfg).DeleteAccessKey(ctx, &awsiam.DeleteAccessKeyInput{AccessKeyId: aws.String(oldCreds.AccessKeyID)})
+	if err != nil {
+		return fmt.Errorf("the new access key %s is stored in the keyring but the old access key %s could not be deleted, delete it in the IAM console: %w", newCreds.AccessKeyID, oldCreds.AccessKeyID, err)
+	}
+
+	p.clearSessions(ctx, configFile)
+
+	clio.Successf("Replaced access key %s of profile %s with %s", oldCreds.AccessKeyID, p.Profile, newCreds.AccessKeyID)
+
+	return nil
+}
+
+// verify waits until STS accepts the new access key
+func (p *Rotate) verify(ctx context.Context, creds aws.Credentials, region string) error {
+	cfg, err := newConfig(ctx, creds, region)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, err = verify(ctx, cfg)
+		if err == nil || attempt == verifyAttempts {
+			return err
+		}
+
+		clio.Debugf("new access key not accepted yet (attempt %d/%d): %s", attempt, verifyAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(verifyInterval):
+		}
+	}
+}
+
+// rollback restores the old access key in the keyring and deletes the new one
+func (p *Rotate) rollback(ctx context.Context, store securestorage.IAMCredentialsSecureStorage, client *awsiam.Client, oldCreds aws.Credentials, newKeyID string) {
+	if err := store.StoreCredentials(ctx, p.Profile, oldCreds); err != nil {
+		clio.Errorf("failed to restore the old access key %s in the keyring: %s", oldCreds.AccessKeyID, err)
+	}
+
+	_, err := client.DeleteAccessKey(context.WithoutCancel(ctx), &awsiam.DeleteAccessKeyInput{AccessKeyId: aws.String(newKeyID)})
+	if err != nil {
+		clio.Errorf("failed to delete the new access key %s, delete it in the IAM console: %s", newKeyID, err)
+	}
+}
+
+// clearSessions removes the credentials cached with the old access key
+func (p *Rotate) clearSessions(ctx context.Context, configFile *ini.File) {
+	// a copy of the access key is cached along with the role credentials
+	roleStore := securestorage.NewSecureRoleCredentialsStorage()
+	roleStore.ClearCredentials(ctx, p.Profile)
+
+	section, err := configFile.GetSection(configSection(p.Profile))
+	if err != nil {
+		return
+	}
+
+	if serial, err := section.GetKey("mfa_serial"); err == nil && serial.String() != "" {
+		mfaStore := securestorage.NewSecureMFASessionStorage()
+		mfaStore.ClearSession(ctx, serial.String())
+	}
+}
@@ -1,10 +1,29 @@
 package keyring
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
+// names of the backends that can be selected with Configure
+const (
+	BackendSecretService = "secret-service"
+	BackendKeychain      = "keychain"
+	BackendWinCred       = "wincred"
+	BackendFile          = "file"
+	BackendPass          = "pass"
+)
+
+// DefaultTimeout is how long an operation can take when no timeout is configured
+const DefaultTimeout = 3 * time.Second
+
+// how long to wait for a provider to return after its context is done
+const cancelGrace = 500 * time.Millisecond
+
 var (
 	// ErrKeyringNotFound is the expected error if the secret isn't found in the
 	// keyring.
@@ -16,79 +35,194 @@ var (
 	ErrSetDataTooBig  = errors.New("data passed to Set was too big")
 	ErrKeyringTimeout = errors.New("timeout while accessing keyring")
 
+	// errUnlockPrompt is wrapped by providers that were waiting for the user to unlock the keyring
+	// when their context was done, the operation is retried once
+	errUnlockPrompt = errors.New("keyring unlock prompt was shown")
+
+	// timeout of each operation, set by Configure
+	timeout = DefaultTimeout
+
 	// provider is set in platform-specific init functions (e.g. keyring_unix.go)
 	// and receives the service value from this package internals.
 	provider Keyring = fallbackServiceProvider{}
+
+	// native backend of the platform, set with the provider in platform-specific init functions
+	defaultBackend string
+
+	// backends available on this platform, the native one is added by the platform-specific init function
+	backends = map[string]func(Config) (Keyring, error){
+		BackendFile: newFileProvider,
+		BackendPass: newPassProvider,
+	}
 )
 
+// Config selects the backend used to store secrets and its settings, empty values use the defaults
+type Config struct {
+	// name of the backend, the native one of the platform if empty
+	Backend string
+	// directory of the encrypted files of the file backend
+	FileDir string
+	// file with the passphrase of the file backend, used when AWS_FUZZY_KEYRING_PASSPHRASE is not set
+	FilePassphraseFile string
+	// prefix of the entries of the pass backend in the password store
+	PassPrefix string
+	// label of the Secret Service collection, the login collection if empty
+	LibSecretCollectionName string
+	// how long an operation can take, DefaultTimeout if zero
+	Timeout time.Duration
+}
+
+// Configure replaces the provider with the one of the configured backend, or of the native one if none is configured
+func Configure(cfg Config) error {
+	timeout = DefaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = cfg.Timeout
+	}
+
+	if cfg.Backend == "" {
+		cfg.Backend = defaultBackend
+	}
+	if cfg.Backend == "" {
+		return nil
+	}
+
+	newProvider, ok := backends[cfg.Backend]
+	if !ok {
+		names := make([]string, 0, len(backends))
+		for name := range backends {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("keyring backend '%s' is not available on this platform, expected one of: %s", cfg.Backend, strings.Join(names, ", "))
+	}
+
+	p, err := newProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to setup the %s keyring backend, %w", cfg.Backend, err)
+	}
+
+	provider = p
+	return nil
+}
+
 // Keyring defines the internal provider contract used by OS-specific backends.
 //
 // The service argument is provider-level only. Public functions in this package
-// always pass the caller-supplied service name.
+// always pass the caller-supplied service name. Providers stop waiting for the
+// keyring when the context is done.
 type Keyring interface {
 	// Set stores secret for user under service.
-	Set(service, user, secret string) error
+	Set(ctx context.Context, service, user, secret string) error
 	// Get returns secret for user under service.
-	Get(service, user string) (string, error)
+	Get(ctx context.Context, service, user string) (string, error)
 	// Delete removes secret for user under service.
-	Delete(service, user string) error
+	Delete(ctx context.Context, service, user string) error
+	// List returns the users with a secret under service.
+	List(ctx context.Context, service string) ([]string, error)
 	// DeleteAll removes all secrets under service.
-	DeleteAll(service string) error
+	DeleteAll(ctx context.Context, service string) error
 }
 
-// Set secret in keyring for user under the given service.
-func Set(service, user, secret string) error {
-	ch := make(chan error, 1)
-	go func() {
-		defer close(ch)
-		ch <- provider.Set(service, user, secret)
-	}()
-	select {
-	case err := <-ch:
-		return err
-	case <-time.After(3 * time.Second):
-		return ErrKeyringTimeout
+// SetProvider replaces the provider, e.g. with NewMemoryKeyring in tests.
+// It returns a function that restores the previous provider.
+func SetProvider(k Keyring) func() {
+	previous := provider
+	provider = k
+
+	return func() { provider = previous }
+}
+
+// result of an operation, only the value of the operation is set
+type result struct {
+	secret string
+	users  []string
+	err    error
+}
+
+// do runs op with the configured timeout, it is retried once if the keyring
+// asked the user to unlock it while op was running
+func do(ctx context.Context, op func(context.Context) result) result {
+	res := attempt(ctx, op)
+	if errors.Is(res.err, errUnlockPrompt) {
+		res = attempt(ctx, op)
 	}
+	if errors.Is(res.err, errUnlockPrompt) {
+		res.err = ErrKeyringTimeout
+	}
+
+	return res
 }
 
-// Get secret from keyring for user under the given service.
-func Get(service, user string) (string, error) {
-	ch := make(chan struct {
-		val string
-		err error
-	}, 1)
+func attempt(ctx context.Context, op func(context.Context) result) result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ch := make(chan result, 1)
 	go func() {
-		defer close(ch)
-		val, err := provider.Get(service, user)
-		ch <- struct {
-			val string
-			err error
-		}{val, err}
+		ch <- op(ctx)
 	}()
+
 	select {
 	case res := <-ch:
-		return res.val, res.err
-	case <-time.After(3 * time.Second):
-		return "", ErrKeyringTimeout
+		return timeoutResult(res)
+	case <-ctx.Done():
 	}
-}
 
-// Delete secret from keyring for user under the given service.
-func Delete(service, user string) error {
-	ch := make(chan error, 1)
-	go func() {
-		defer close(ch)
-		ch <- provider.Delete(service, user)
-	}()
+	// providers return soon after their context is done, the result tells if they were waiting for an unlock prompt
 	select {
-	case err := <-ch:
-		return err
-	case <-time.After(3 * time.Second):
-		return ErrKeyringTimeout
+	case res := <-ch:
+		return timeoutResult(res)
+	case <-time.After(cancelGrace):
+		return result{err: ErrKeyringTimeout}
+	}
+}
+
+func timeoutResult(res result) result {
+	if errors.Is(res.err, context.DeadlineExceeded) && !errors.Is(res.err, errUnlockPrompt) {
+		res.err = ErrKeyringTimeout
 	}
+
+	return res
+}
+
+// Set secret in keyring for user under the given service.
+func Set(ctx context.Context, service, user, secret string) error {
+	return do(ctx, func(ctx context.Context) result {
+		return result{err: provider.Set(ctx, service, user, secret)}
+	}).err
+}
+
+// Get secret from keyring for user under the given service.
+func Get(ctx context.Context, service, user string) (string, error) {
+	res := do(ctx, func(ctx context.Context) result {
+		secret, err := provider.Get(ctx, service, user)
+		return result{secret: secret, err: err}
+	})
+
+	return res.secret, res.err
+}
+
+// Delete secret from keyring for user under the given service.
+func Delete(ctx context.Context, service, user string) error {
+	return do(ctx, func(ctx context.Context) result {
+		return result{err: provider.Delete(ctx, service, user)}
+	}).err
+}
+
+// List returns the users with a secret under the given service, without reading the secrets.
+func List(ctx context.Context, service string) ([]string, error) {
+	res := do(ctx, func(ctx context.Context) result {
+		users, err := provider.List(ctx, service)
+		return result{users: users, err: err}
+	})
+
+	return res.users, res.err
 }
 
 // DeleteAll deletes all secrets for the given service.
-func DeleteAll(service string) error {
-	return provider.DeleteAll(service)
+func DeleteAll(ctx context.Context, service string) error {
+	return do(ctx, func(ctx context.Context) result {
+		return result{err: provider.DeleteAll(ctx, service)}
+	}).err
 }
@@ -15,6 +15,7 @@
 package keyring
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"os/exec"
@@ -32,8 +33,8 @@ const (
 type macOSXKeychain struct{}
 
 // Get returns a secret for username under service.
-func (k macOSXKeychain) Get(service, username string) (string, error) {
-	out, err := exec.Command(
+func (k macOSXKeychain) Get(ctx context.Context, service, username string) (string, error) {
+	out, err := exec.CommandContext(ctx,
 		execPathKeychain,
 		"find-generic-password",
 		"-s", service,
@@ -59,7 +60,7 @@ func (k macOSXKeychain) Get(service, username string) (string, error) {
 }
 
 // Set stores a secret for username under service.
-func (k macOSXKeychain) Set(service, username, password string) error {
+func (k macOSXKeychain) Set(ctx context.Context, service, username, password string) error {
 	// if the added secret has multiple lines or some non ascii,
 	// osx will hex encode it on return. To avoid getting garbage, we
 	// encode all passwords
@@ -68,9 +69,9 @@ func (k macOSXKeychain) Set(service, username, password string) error {
 	// Delete any existing item before creating a new one. Using -U (update) on
 	// an item created with different ACLs triggers a macOS keychain password
 	// prompt to "change access permissions". Creating fresh with -A avoids this.
-	_ = exec.Command(execPathKeychain, "delete-generic-password", "-s", service, "-a", username).Run()
+	_ = exec.CommandContext(ctx, execPathKeychain, "delete-generic-password", "-s", service, "-a", username).Run()
 
-	out, err := exec.Command(
+	out, err := exec.CommandContext(ctx,
 		execPathKeychain,
 		"add-generic-password",
 		"-A",
@@ -89,8 +90,8 @@ func (k macOSXKeychain) Set(service, username, password string) error {
 }
 
 // Delete removes a secret for username under service.
-func (k macOSXKeychain) Delete(service, username string) error {
-	out, err := exec.Command(
+func (k macOSXKeychain) Delete(ctx context.Context, service, username string) error {
+	out, err := exec.CommandContext(ctx,
 		execPathKeychain,
 		"delete-generic-password",
 		"-s", service,
@@ -101,8 +102,61 @@ func (k macOSXKeychain) Delete(service, username string) error {
 	return err
 }
 
+// List returns the usernames with a secret under service, the attributes of the items are
+// read from the dump of the keychain which does not include the secrets.
+func (k macOSXKeychain) List(ctx context.Context, service string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, execPathKeychain, "dump-keychain").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := []string{}
+
+	var account, svce string
+	flush := func() {
+		if svce == service {
+			usernames = append(usernames, account)
+		}
+		account, svce = "", ""
+	}
+
+	// every item starts with its keychain followed by its attributes, e.g.
+	//     "acct"<blob>="username"
+	//     "svce"<blob>="service"
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "keychain:"):
+			flush()
+		case strings.HasPrefix(line, `"acct"<blob>=`):
+			account = keychainAttribute(line)
+		case strings.HasPrefix(line, `"svce"<blob>=`):
+			svce = keychainAttribute(line)
+		}
+	}
+	flush()
+
+	return usernames, nil
+}
+
+// keychainAttribute returns the quoted value of an attribute line of dump-keychain
+func keychainAttribute(line string) string {
+	_, value, _ := strings.Cut(line, "<blob>=")
+	value = strings.TrimSpace(value)
+
+	// values that are not printable are dumped as 0x<hex>  "<escaped>"
+	if strings.HasPrefix(value, "0x") {
+		fields := strings.Fields(value)
+		if dec, err := hex.DecodeString(strings.TrimPrefix(fields[0], "0x")); err == nil {
+			return string(dec)
+		}
+	}
+
+	return strings.Trim(value, `"`)
+}
+
 // DeleteAll removes all secrets under service.
-func (k macOSXKeychain) DeleteAll(service string) error {
+func (k macOSXKeychain) DeleteAll(ctx context.Context, service string) error {
 	// if service is empty, do nothing otherwise it might accidentally delete all secrets
 	if service == "" {
 		return ErrKeyringNotFound
@@ -110,7 +164,7 @@ func (k macOSXKeychain) DeleteAll(service string) error {
 	// Delete each secret in a while loop until there is no more left
 	// under the service
 	for {
-		out, err := exec.Command(
+		out, err := exec.CommandContext(ctx,
 			execPathKeychain,
 			"delete-generic-password",
 			"-s", service).CombinedOutput()
@@ -125,4 +179,6 @@ func (k macOSXKeychain) DeleteAll(service string) error {
 
 func init() {
 	provider = macOSXKeychain{}
+	defaultBackend = BackendKeychain
+	backends[BackendKeychain] = func(Config) (Keyring, error) { return macOSXKeychain{}, nil }
 }
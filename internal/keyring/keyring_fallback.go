@@ -1,6 +1,7 @@
 package keyring
 
 import (
+	"context"
 	"errors"
 	"runtime"
 )
@@ -11,18 +12,22 @@ var ErrUnsupportedPlatform = errors.New("unsupported platform: " + runtime.GOOS)
 // fallbackServiceProvider implements the internal provider contract for unsupported OSes.
 type fallbackServiceProvider struct{}
 
-func (fallbackServiceProvider) Set(service, user, pass string) error {
+func (fallbackServiceProvider) Set(ctx context.Context, service, user, pass string) error {
 	return ErrUnsupportedPlatform
 }
 
-func (fallbackServiceProvider) Get(service, user string) (string, error) {
+func (fallbackServiceProvider) Get(ctx context.Context, service, user string) (string, error) {
 	return "", ErrUnsupportedPlatform
 }
 
-func (fallbackServiceProvider) Delete(service, user string) error {
+func (fallbackServiceProvider) Delete(ctx context.Context, service, user string) error {
 	return ErrUnsupportedPlatform
 }
 
-func (fallbackServiceProvider) DeleteAll(service string) error {
+func (fallbackServiceProvider) List(ctx context.Context, service string) ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func (fallbackServiceProvider) DeleteAll(ctx context.Context, service string) error {
 	return ErrUnsupportedPlatform
 }
@@ -0,0 +1,343 @@
+package keyring
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// PassphraseEnvVar is the environment variable with the passphrase of the file backend
+	PassphraseEnvVar = "AWS_FUZZY_KEYRING_PASSPHRASE"
+
+	// salt of the key derivation, shared by every secret of the directory
+	fileSaltName = ".salt"
+	// known secret used to detect a wrong passphrase before reading or writing secrets
+	fileCheckName  = ".check"
+	fileCheckValue = "aws-fuzzy"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	nonceSize = 24
+)
+
+var errWrongPassphrase = errors.New("wrong keyring passphrase")
+
+// fileProvider stores every secret in its own file encrypted with NaCl secretbox,
+// the key is derived from a passphrase with scrypt
+type fileProvider struct {
+	dir string
+	key [32]byte
+}
+
+func newFileProvider(cfg Config) (Keyring, error) {
+	if cfg.FileDir == "" {
+		return nil, errors.New("no directory configured for the file keyring")
+	}
+
+	if err := os.MkdirAll(cfg.FileDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// a new keyring has no check secret yet
+	_, err := os.Stat(filepath.Join(cfg.FileDir, fileCheckName))
+	created := errors.Is(err, fs.ErrNotExist)
+
+	salt, err := fileSalt(cfg.FileDir)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := filePassphrase(cfg, created)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &fileProvider{dir: cfg.FileDir}
+	copy(p.key[:], derived)
+
+	if err := p.verify(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// fileSalt returns the salt of the directory, it is created with a new keyring
+func fileSalt(dir string) ([]byte, error) {
+	path := filepath.Join(dir, fileSaltName)
+
+	salt, err := os.ReadFile(path)
+	if err == nil {
+		return salt, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	salt = make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, writeFileAtomic(path, salt)
+}
+
+// filePassphrase returns the passphrase from the environment, the passphrase file or asks for it
+func filePassphrase(cfg Config, created bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if cfg.FilePassphraseFile != "" {
+		b, err := os.ReadFile(cfg.FilePassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the keyring passphrase file, %w", err)
+		}
+
+		passphrase := strings.TrimSpace(string(b))
+		if passphrase == "" {
+			return "", fmt.Errorf("keyring passphrase file %s is empty", cfg.FilePassphraseFile)
+		}
+
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no keyring passphrase, set %s or FilePassphraseFile", PassphraseEnvVar)
+	}
+
+	message := "Keyring passphrase: "
+	if created {
+		message = "Passphrase of the new keyring: "
+	}
+	fmt.Fprint(os.Stderr, message)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b) == 0 {
+		return "", errors.New("empty keyring passphrase")
+	}
+
+	return string(b), nil
+}
+
+// verify checks that the passphrase opens the check secret, which is created with a new keyring
+func (p *fileProvider) verify() error {
+	path := filepath.Join(p.dir, fileCheckName)
+
+	value, err := p.read(path)
+	if errors.Is(err, ErrKeyringNotFound) {
+		return p.write(path, fileCheckValue)
+	}
+	if err != nil {
+		return err
+	}
+
+	if value != fileCheckValue {
+		return errWrongPassphrase
+	}
+
+	return nil
+}
+
+// serviceDir returns the directory with the secrets of the service
+func (p *fileProvider) serviceDir(service string) (string, error) {
+	if service == "" || service != filepath.Base(service) || strings.HasPrefix(service, ".") {
+		return "", fmt.Errorf("invalid keyring service name '%s'", service)
+	}
+
+	return filepath.Join(p.dir, service), nil
+}
+
+// path returns the file of the secret, users are encoded because they can be urls
+func (p *fileProvider) path(service, user string) (string, error) {
+	dir, err := p.serviceDir(service)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, base64.RawURLEncoding.EncodeToString([]byte(user))), nil
+}
+
+func (p *fileProvider) read(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrKeyringNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("corrupted keyring file %s", path)
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], data[:nonceSize])
+
+	secret, ok := secretbox.Open(nil, data[nonceSize:], &nonce, &p.key)
+	if !ok {
+		return "", errWrongPassphrase
+	}
+
+	return string(secret), nil
+}
+
+func (p *fileProvider) write(path, secret string) error {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return err
+	}
+
+	data := secretbox.Seal(nonce[:], []byte(secret), &nonce, &p.key)
+
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces the file so readers never see a partial secret
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Set stores secret for user under service.
+func (p *fileProvider) Set(ctx context.Context, service, user, secret string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path, err := p.path(service, user)
+	if err != nil {
+		return err
+	}
+
+	return p.write(path, secret)
+}
+
+// Get returns secret for user under service.
+func (p *fileProvider) Get(ctx context.Context, service, user string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	path, err := p.path(service, user)
+	if err != nil {
+		return "", err
+	}
+
+	return p.read(path)
+}
+
+// Delete removes secret for user under service.
+func (p *fileProvider) Delete(ctx context.Context, service, user string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path, err := p.path(service, user)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrKeyringNotFound
+	}
+
+	return err
+}
+
+// List returns the users with a secret under service.
+func (p *fileProvider) List(ctx context.Context, service string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dir, err := p.serviceDir(service)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// skip the temporary files of writeFileAtomic
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		user, err := base64.RawURLEncoding.DecodeString(entry.Name())
+		if err != nil {
+			continue
+		}
+		users = append(users, string(user))
+	}
+
+	return users, nil
+}
+
+// DeleteAll removes all secrets under service.
+func (p *fileProvider) DeleteAll(ctx context.Context, service string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// if service is empty, do nothing otherwise it might accidentally delete all secrets
+	if service == "" {
+		return ErrKeyringNotFound
+	}
+
+	dir, err := p.serviceDir(service)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,124 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnvVar, "correct horse battery staple")
+
+	p, err := newFileProvider(Config{FileDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := p.Get(ctx, "aws-fuzzy-sso-tokens", "https://example.awsapps.com/start"); !errors.Is(err, ErrKeyringNotFound) {
+		t.Errorf("expected ErrKeyringNotFound, got %v", err)
+	}
+
+	if err := p.Set(ctx, "aws-fuzzy-sso-tokens", "https://example.awsapps.com/start", `{"token":"secret"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Set(ctx, "aws-fuzzy-sso-tokens", "other", "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := p.Get(ctx, "aws-fuzzy-sso-tokens", "https://example.awsapps.com/start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != `{"token":"secret"}` {
+		t.Errorf("got %q", got)
+	}
+
+	// secrets are not stored in plain text
+	files, _ := filepath.Glob(filepath.Join(dir, "aws-fuzzy-sso-tokens", "*"))
+	for _, file := range files {
+		b, _ := os.ReadFile(file)
+		if strings.Contains(string(b), "secret") {
+			t.Errorf("file %s contains the secret in plain text", file)
+		}
+	}
+
+	// a new provider with the same passphrase reads the secrets
+	p2, err := newFileProvider(Config{FileDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := p2.Get(ctx, "aws-fuzzy-sso-tokens", "other"); got != "value" {
+		t.Errorf("got %q, want value", got)
+	}
+
+	if err := p.Delete(ctx, "aws-fuzzy-sso-tokens", "other"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Delete(ctx, "aws-fuzzy-sso-tokens", "other"); !errors.Is(err, ErrKeyringNotFound) {
+		t.Errorf("expected ErrKeyringNotFound, got %v", err)
+	}
+
+	if err := p.Set(ctx, "aws-fuzzy-eks-tokens", "dev:cluster", "token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	users, err := p.List(ctx, "aws-fuzzy-sso-tokens")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0] != "https://example.awsapps.com/start" {
+		t.Errorf("got %v, want the start url", users)
+	}
+
+	if err := p.DeleteAll(ctx, "aws-fuzzy-sso-tokens"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.Get(ctx, "aws-fuzzy-sso-tokens", "https://example.awsapps.com/start"); !errors.Is(err, ErrKeyringNotFound) {
+		t.Errorf("expected ErrKeyringNotFound after DeleteAll, got %v", err)
+	}
+	if users, _ := p.List(ctx, "aws-fuzzy-sso-tokens"); len(users) != 0 {
+		t.Errorf("got %v after DeleteAll, want no users", users)
+	}
+
+	// other services are kept
+	if got, _ := p.Get(ctx, "aws-fuzzy-eks-tokens", "dev:cluster"); got != "token" {
+		t.Errorf("got %q, want token", got)
+	}
+
+	if err := p.Set(ctx, "../escape", "user", "value"); err == nil {
+		t.Error("expected an error for a service outside of the directory")
+	}
+}
+
+func TestFileProviderWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv(PassphraseEnvVar, "first")
+	if _, err := newFileProvider(Config{FileDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Setenv(PassphraseEnvVar, "second")
+	if _, err := newFileProvider(Config{FileDir: dir}); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("expected errWrongPassphrase, got %v", err)
+	}
+
+	// the passphrase can also be read from a file
+	t.Setenv(PassphraseEnvVar, "")
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	_ = os.WriteFile(passphraseFile, []byte("first\n"), 0600)
+	if _, err := newFileProvider(Config{FileDir: dir, FilePassphraseFile: passphraseFile}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConfigureUnknownBackend(t *testing.T) {
+	if err := Configure(Config{Backend: "nope"}); err == nil || !strings.Contains(err.Error(), BackendFile) {
+		t.Errorf("expected an error listing the available backends, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package keyring
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// memoryProvider keeps secrets in memory, it is meant for tests of the packages that store secrets
+type memoryProvider struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+}
+
+// NewMemoryKeyring returns an empty keyring that keeps secrets in memory, to be used with SetProvider
+func NewMemoryKeyring() Keyring {
+	return &memoryProvider{secrets: map[string]map[string]string{}}
+}
+
+// Set stores secret for user under service.
+func (m *memoryProvider) Set(ctx context.Context, service, user, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.secrets[service] == nil {
+		m.secrets[service] = map[string]string{}
+	}
+	m.secrets[service][user] = secret
+
+	return nil
+}
+
+// Get returns secret for user under service.
+func (m *memoryProvider) Get(ctx context.Context, service, user string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	secret, ok := m.secrets[service][user]
+	if !ok {
+		return "", ErrKeyringNotFound
+	}
+
+	return secret, nil
+}
+
+// Delete removes secret for user under service.
+func (m *memoryProvider) Delete(ctx context.Context, service, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.secrets[service][user]; !ok {
+		return ErrKeyringNotFound
+	}
+	delete(m.secrets[service], user)
+
+	return nil
+}
+
+// List returns the users with a secret under service.
+func (m *memoryProvider) List(ctx context.Context, service string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]string, 0, len(m.secrets[service]))
+	for user := range m.secrets[service] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	return users, nil
+}
+
+// DeleteAll removes all secrets under service.
+func (m *memoryProvider) DeleteAll(ctx context.Context, service string) error {
+	// if service is empty, do nothing otherwise it might accidentally delete all secrets
+	if service == "" {
+		return ErrKeyringNotFound
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.secrets, service)
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package keyring
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// prefix of the entries in the password store when none is configured
+const defaultPassPrefix = "aws-fuzzy"
+
+// passProvider stores secrets in the password store of pass(1), encrypted with the gpg key of the store
+type passProvider struct {
+	prefix string
+}
+
+func newPassProvider(cfg Config) (Keyring, error) {
+	if _, err := exec.LookPath("pass"); err != nil {
+		return nil, errors.New("pass(1) not found in PATH")
+	}
+
+	prefix := cfg.PassPrefix
+	if prefix == "" {
+		prefix = defaultPassPrefix
+	}
+
+	return passProvider{prefix: prefix}, nil
+}
+
+// entry returns the name of the secret in the store, users are encoded because they can be urls
+func (p passProvider) entry(service, user string) string {
+	return path.Join(p.prefix, service, base64.RawURLEncoding.EncodeToString([]byte(user)))
+}
+
+// run runs pass with args and returns its output, a missing entry returns ErrKeyringNotFound
+func (p passProvider) run(ctx context.Context, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "pass", args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if strings.Contains(stderr.String(), "is not in the password store") {
+			return "", ErrKeyringNotFound
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// Set stores secret for user under service.
+func (p passProvider) Set(ctx context.Context, service, user, secret string) error {
+	_, err := p.run(ctx, secret, "insert", "--multiline", "--force", p.entry(service, user))
+	return err
+}
+
+// Get returns secret for user under service.
+func (p passProvider) Get(ctx context.Context, service, user string) (string, error) {
+	return p.run(ctx, "", "show", p.entry(service, user))
+}
+
+// Delete removes secret for user under service.
+func (p passProvider) Delete(ctx context.Context, service, user string) error {
+	_, err := p.run(ctx, "", "rm", "--force", p.entry(service, user))
+	return err
+}
+
+// List returns the users with a secret under service, read from the directory of the password store
+// because the output of pass ls is meant for humans
+func (p passProvider) List(ctx context.Context, service string) ([]string, error) {
+	dir := os.Getenv("PASSWORD_STORE_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".password-store")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(p.prefix), service))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".gpg")
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		user, err := base64.RawURLEncoding.DecodeString(name)
+		if err != nil {
+			continue
+		}
+		users = append(users, string(user))
+	}
+
+	return users, nil
+}
+
+// DeleteAll removes all secrets under service.
+func (p passProvider) DeleteAll(ctx context.Context, service string) error {
+	// if service is empty, do nothing otherwise it might accidentally delete all secrets
+	if service == "" {
+		return ErrKeyringNotFound
+	}
+
+	_, err := p.run(ctx, "", "rm", "--recursive", "--force", path.Join(p.prefix, service))
+	if errors.Is(err, ErrKeyringNotFound) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,107 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// blockingProvider waits for the context on every operation, like a keyring waiting for the user to unlock it
+type blockingProvider struct {
+	fallbackServiceProvider
+	// number of operations that show an unlock prompt before the keyring is unlocked
+	prompts int
+	calls   int
+}
+
+func (b *blockingProvider) Get(ctx context.Context, service, user string) (string, error) {
+	b.calls++
+	if b.calls > b.prompts {
+		return "secret", nil
+	}
+
+	<-ctx.Done()
+	return "", fmt.Errorf("%w: %w", errUnlockPrompt, ctx.Err())
+}
+
+func (b *blockingProvider) Set(ctx context.Context, service, user, secret string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func withTimeout(t *testing.T, d time.Duration) {
+	previous := timeout
+	timeout = d
+	t.Cleanup(func() { timeout = previous })
+}
+
+func TestTimeout(t *testing.T) {
+	withTimeout(t, 10*time.Millisecond)
+	t.Cleanup(SetProvider(&blockingProvider{}))
+
+	if err := Set(context.Background(), "service", "user", "secret"); !errors.Is(err, ErrKeyringTimeout) {
+		t.Errorf("expected ErrKeyringTimeout, got %v", err)
+	}
+}
+
+func TestRetryAfterUnlockPrompt(t *testing.T) {
+	withTimeout(t, 10*time.Millisecond)
+
+	p := &blockingProvider{prompts: 1}
+	t.Cleanup(SetProvider(p))
+
+	got, err := Get(context.Background(), "service", "user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "secret" || p.calls != 2 {
+		t.Errorf("got %q after %d calls, want the secret after 2 calls", got, p.calls)
+	}
+
+	// only one retry
+	p = &blockingProvider{prompts: 2}
+	SetProvider(p)
+
+	if _, err := Get(context.Background(), "service", "user"); !errors.Is(err, ErrKeyringTimeout) {
+		t.Errorf("expected ErrKeyringTimeout, got %v", err)
+	}
+	if p.calls != 2 {
+		t.Errorf("got %d calls, want 2", p.calls)
+	}
+}
+
+func TestMemoryKeyring(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(SetProvider(NewMemoryKeyring()))
+
+	if _, err := Get(ctx, "service", "user"); !errors.Is(err, ErrKeyringNotFound) {
+		t.Errorf("expected ErrKeyringNotFound, got %v", err)
+	}
+
+	for _, user := range []string{"b", "a"} {
+		if err := Set(ctx, "service", user, "secret-"+user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, _ := Get(ctx, "service", "a"); got != "secret-a" {
+		t.Errorf("got %q, want secret-a", got)
+	}
+
+	users, _ := List(ctx, "service")
+	if len(users) != 2 || users[0] != "a" || users[1] != "b" {
+		t.Errorf("got %v, want [a b]", users)
+	}
+
+	if err := Delete(ctx, "service", "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := DeleteAll(ctx, "service"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if users, _ := List(ctx, "service"); len(users) != 0 {
+		t.Errorf("got %v after DeleteAll, want no users", users)
+	}
+}
@@ -3,27 +3,51 @@
 package keyring
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	dbus "github.com/godbus/dbus/v5"
 	ss "github.com/AndreZiviani/aws-fuzzy/internal/keyring/secret_service"
 )
 
-type secretServiceProvider struct{}
+// secretServiceProvider stores secrets in the collection with the configured name,
+// or in the login collection if none is configured
+type secretServiceProvider struct {
+	collection string
+}
+
+// getCollection returns the collection of the secrets, the configured one is created if it does not exist
+func (s secretServiceProvider) getCollection(ctx context.Context, svc *ss.SecretService) (dbus.BusObject, error) {
+	if s.collection == "" {
+		return svc.GetLoginCollection(), nil
+	}
+
+	collection, err := svc.GetCollectionByLabel(s.collection)
+	if err != nil {
+		return nil, err
+	}
+	if collection != nil {
+		return collection, nil
+	}
 
-// Set stores pass for user under service.
-func (s secretServiceProvider) Set(service, user, pass string) error {
+	collection, err = svc.CreateCollection(ctx, s.collection)
+	return collection, unlockError(err)
+}
+
+// Set stores pass for user under service, replacing the existing item.
+func (s secretServiceProvider) Set(ctx context.Context, service, user, pass string) error {
 	svc, err := ss.NewSecretService()
 	if err != nil {
 		return err
 	}
 
 	// open a session
-	session, err := svc.OpenSession()
+	session, err := svc.OpenSession(ctx)
 	if err != nil {
 		return err
 	}
-	defer svc.Close(session)
+	defer svc.Close(ctx, session)
 
 	attributes := map[string]string{
 		"username": user,
@@ -32,38 +56,67 @@ func (s secretServiceProvider) Set(service, user, pass string) error {
 
 	secret := ss.NewSecret(session.Path(), pass)
 
-	collection := svc.GetLoginCollection()
+	// the label identifies the kind of secret and the profile or session it belongs to
+	label := fmt.Sprintf("%s: %s", service, user)
 
-	err = svc.Unlock(collection.Path())
+	collection, err := s.getCollection(ctx, svc)
 	if err != nil {
 		return err
 	}
 
-	err = svc.CreateItem(collection,
-		fmt.Sprintf("Password for '%s' on '%s'", user, service),
-		attributes, secret)
+	err = svc.Unlock(ctx, collection.Path())
+	if err != nil {
+		return unlockError(err)
+	}
+
+	items, err := svc.SearchItems(ctx, collection, attributes)
 	if err != nil {
 		return err
 	}
 
+	if len(items) == 0 {
+		return svc.CreateItem(ctx, collection, label, attributes, secret)
+	}
+
+	err = svc.SetSecret(ctx, items[0], secret)
+	if err != nil {
+		return err
+	}
+
+	err = svc.SetLabel(items[0], label)
+	if err != nil {
+		return err
+	}
+
+	// previous versions created a new item on every Set
+	for _, item := range items[1:] {
+		err = svc.Delete(ctx, item)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // findItem looks up an item by service and user.
-func (s secretServiceProvider) findItem(svc *ss.SecretService, service, user string) (dbus.ObjectPath, error) {
-	collection := svc.GetLoginCollection()
+func (s secretServiceProvider) findItem(ctx context.Context, svc *ss.SecretService, service, user string) (dbus.ObjectPath, error) {
+	collection, err := s.getCollection(ctx, svc)
+	if err != nil {
+		return "", err
+	}
 
 	search := map[string]string{
 		"username": user,
 		"service":  service,
 	}
 
-	err := svc.Unlock(collection.Path())
+	err = svc.Unlock(ctx, collection.Path())
 	if err != nil {
-		return "", err
+		return "", unlockError(err)
 	}
 
-	results, err := svc.SearchItems(collection, search)
+	results, err := svc.SearchItems(ctx, collection, search)
 	if err != nil {
 		return "", err
 	}
@@ -76,19 +129,22 @@ func (s secretServiceProvider) findItem(svc *ss.SecretService, service, user str
 }
 
 // findServiceItems looks up all items by service.
-func (s secretServiceProvider) findServiceItems(svc *ss.SecretService, service string) ([]dbus.ObjectPath, error) {
-	collection := svc.GetLoginCollection()
+func (s secretServiceProvider) findServiceItems(ctx context.Context, svc *ss.SecretService, service string) ([]dbus.ObjectPath, error) {
+	collection, err := s.getCollection(ctx, svc)
+	if err != nil {
+		return []dbus.ObjectPath{}, err
+	}
 
 	search := map[string]string{
 		"service": service,
 	}
 
-	err := svc.Unlock(collection.Path())
+	err = svc.Unlock(ctx, collection.Path())
 	if err != nil {
-		return []dbus.ObjectPath{}, err
+		return []dbus.ObjectPath{}, unlockError(err)
 	}
 
-	results, err := svc.SearchItems(collection, search)
+	results, err := svc.SearchItems(ctx, collection, search)
 	if err != nil {
 		return []dbus.ObjectPath{}, err
 	}
@@ -101,31 +157,31 @@ func (s secretServiceProvider) findServiceItems(svc *ss.SecretService, service s
 }
 
 // Get returns a secret for user under service.
-func (s secretServiceProvider) Get(service, user string) (string, error) {
+func (s secretServiceProvider) Get(ctx context.Context, service, user string) (string, error) {
 	svc, err := ss.NewSecretService()
 	if err != nil {
 		return "", err
 	}
 
-	item, err := s.findItem(svc, service, user)
+	item, err := s.findItem(ctx, svc, service, user)
 	if err != nil {
 		return "", err
 	}
 
 	// open a session
-	session, err := svc.OpenSession()
+	session, err := svc.OpenSession(ctx)
 	if err != nil {
 		return "", err
 	}
-	defer svc.Close(session)
+	defer svc.Close(ctx, session)
 
 	// unlock if individual item is locked
-	err = svc.Unlock(item)
+	err = svc.Unlock(ctx, item)
 	if err != nil {
-		return "", err
+		return "", unlockError(err)
 	}
 
-	secret, err := svc.GetSecret(item, session.Path())
+	secret, err := svc.GetSecret(ctx, item, session.Path())
 	if err != nil {
 		return "", err
 	}
@@ -134,22 +190,49 @@ func (s secretServiceProvider) Get(service, user string) (string, error) {
 }
 
 // Delete removes a secret for user under service.
-func (s secretServiceProvider) Delete(service, user string) error {
+func (s secretServiceProvider) Delete(ctx context.Context, service, user string) error {
 	svc, err := ss.NewSecretService()
 	if err != nil {
 		return err
 	}
 
-	item, err := s.findItem(svc, service, user)
+	item, err := s.findItem(ctx, svc, service, user)
 	if err != nil {
 		return err
 	}
 
-	return svc.Delete(item)
+	return svc.Delete(ctx, item)
+}
+
+// List returns the users with a secret under service.
+func (s secretServiceProvider) List(ctx context.Context, service string) ([]string, error) {
+	svc, err := ss.NewSecretService()
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.findServiceItems(ctx, svc, service)
+	if err != nil {
+		if err == ErrKeyringNotFound {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	users := make([]string, 0, len(items))
+	for _, item := range items {
+		attributes, err := svc.GetAttributes(item)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, attributes["username"])
+	}
+
+	return users, nil
 }
 
 // DeleteAll removes all secrets under service.
-func (s secretServiceProvider) DeleteAll(service string) error {
+func (s secretServiceProvider) DeleteAll(ctx context.Context, service string) error {
 	// if service is empty, do nothing otherwise it might accidentally delete all secrets
 	if service == "" {
 		return ErrKeyringNotFound
@@ -160,7 +243,7 @@ func (s secretServiceProvider) DeleteAll(service string) error {
 		return err
 	}
 	// find all items for the service
-	items, err := s.findServiceItems(svc, service)
+	items, err := s.findServiceItems(ctx, svc, service)
 	if err != nil {
 		if err == ErrKeyringNotFound {
 			return nil
@@ -168,7 +251,7 @@ func (s secretServiceProvider) DeleteAll(service string) error {
 		return err
 	}
 	for _, item := range items {
-		err = svc.Delete(item)
+		err = svc.Delete(ctx, item)
 		if err != nil {
 			return err
 		}
@@ -176,6 +259,19 @@ func (s secretServiceProvider) DeleteAll(service string) error {
 	return nil
 }
 
+// unlockError marks the error of an operation that was cancelled while the user was asked to unlock the keyring
+func unlockError(err error) error {
+	if errors.Is(err, ss.ErrPromptCancelled) {
+		return fmt.Errorf("%w: %w", errUnlockPrompt, err)
+	}
+
+	return err
+}
+
 func init() {
 	provider = secretServiceProvider{}
+	defaultBackend = BackendSecretService
+	backends[BackendSecretService] = func(cfg Config) (Keyring, error) {
+		return secretServiceProvider{collection: cfg.LibSecretCollectionName}, nil
+	}
 }
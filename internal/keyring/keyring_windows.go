@@ -1,6 +1,7 @@
 package keyring
 
 import (
+	"context"
 	"strings"
 	"syscall"
 
@@ -10,7 +11,11 @@ import (
 type windowsKeychain struct{}
 
 // Get returns a secret for username under service.
-func (k windowsKeychain) Get(service, username string) (string, error) {
+func (k windowsKeychain) Get(ctx context.Context, service, username string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	cred, err := wincred.GetGenericCredential(k.credName(service, username))
 	if err != nil {
 		if err == syscall.ERROR_NOT_FOUND {
@@ -23,7 +28,11 @@ func (k windowsKeychain) Get(service, username string) (string, error) {
 }
 
 // Set stores password for username under service.
-func (k windowsKeychain) Set(service, username, password string) error {
+func (k windowsKeychain) Set(ctx context.Context, service, username, password string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// password may not exceed 2560 bytes (https://github.com/jaraco/keyring/issues/540#issuecomment-968329967)
 	if len(password) > 2560 {
 		return ErrSetDataTooBig
@@ -47,7 +56,11 @@ func (k windowsKeychain) Set(service, username, password string) error {
 }
 
 // Delete removes a secret for username under service.
-func (k windowsKeychain) Delete(service, username string) error {
+func (k windowsKeychain) Delete(ctx context.Context, service, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	cred, err := wincred.GetGenericCredential(k.credName(service, username))
 	if err != nil {
 		if err == syscall.ERROR_NOT_FOUND {
@@ -59,8 +72,35 @@ func (k windowsKeychain) Delete(service, username string) error {
 	return cred.Delete()
 }
 
+// List returns the usernames with a secret under service.
+func (k windowsKeychain) List(ctx context.Context, service string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	creds, err := wincred.List()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := k.credName(service, "")
+
+	usernames := []string{}
+	for _, cred := range creds {
+		if strings.HasPrefix(cred.TargetName, prefix) {
+			usernames = append(usernames, strings.TrimPrefix(cred.TargetName, prefix))
+		}
+	}
+
+	return usernames, nil
+}
+
 // DeleteAll removes all secrets under service.
-func (k windowsKeychain) DeleteAll(service string) error {
+func (k windowsKeychain) DeleteAll(ctx context.Context, service string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// if service is empty, do nothing otherwise it might accidentally delete all secrets
 	if service == "" {
 		return ErrKeyringNotFound
@@ -98,4 +138,6 @@ func (k windowsKeychain) credName(service, username string) string {
 
 func init() {
 	provider = windowsKeychain{}
+	defaultBackend = BackendWinCred
+	backends[BackendWinCred] = func(Config) (Keyring, error) { return windowsKeychain{}, nil }
 }
@@ -1,6 +1,7 @@
 package ss
 
 import (
+	"context"
 	"fmt"
 
 	"errors"
@@ -22,6 +23,10 @@ const (
 	collectionBasePath   = "/org/freedesktop/secrets/collection/"
 )
 
+// ErrPromptCancelled is returned when the context is done while a prompt is shown to the user,
+// the prompt is not dismissed so the user can still complete it.
+var ErrPromptCancelled = errors.New("cancelled while waiting for the secret service prompt")
+
 // Secret defines a org.freedesk.Secret.Item secret struct.
 type Secret struct {
 	Session     dbus.ObjectPath
@@ -60,10 +65,10 @@ func NewSecretService() (*SecretService, error) {
 }
 
 // OpenSession opens a secret service session.
-func (s *SecretService) OpenSession() (dbus.BusObject, error) {
+func (s *SecretService) OpenSession(ctx context.Context) (dbus.BusObject, error) {
 	var disregard dbus.Variant
 	var sessionPath dbus.ObjectPath
-	err := s.object.Call(serviceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&disregard, &sessionPath)
+	err := s.object.CallWithContext(ctx, serviceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&disregard, &sessionPath)
 	if err != nil {
 		return nil, err
 	}
@@ -102,16 +107,42 @@ func (s *SecretService) GetLoginCollection() dbus.BusObject {
 	return s.Object(serviceName, path)
 }
 
+// GetCollectionByLabel returns the collection with the label, or nil if there is none.
+func (s *SecretService) GetCollectionByLabel(label string) (dbus.BusObject, error) {
+	val, err := s.object.GetProperty(collectionsInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, ok := val.Value().([]dbus.ObjectPath)
+	if !ok {
+		return nil, errors.New("unexpected collections property")
+	}
+
+	for _, path := range paths {
+		collection := s.Object(serviceName, path)
+		l, err := collection.GetProperty(collectionInterface + ".Label")
+		if err != nil {
+			continue
+		}
+		if name, ok := l.Value().(string); ok && name == label {
+			return collection, nil
+		}
+	}
+
+	return nil, nil
+}
+
 // Unlock unlocks a collection.
-func (s *SecretService) Unlock(collection dbus.ObjectPath) error {
+func (s *SecretService) Unlock(ctx context.Context, collection dbus.ObjectPath) error {
 	var unlocked []dbus.ObjectPath
 	var prompt dbus.ObjectPath
-	err := s.object.Call(serviceInterface+".Unlock", 0, []dbus.ObjectPath{collection}).Store(&unlocked, &prompt)
+	err := s.object.CallWithContext(ctx, serviceInterface+".Unlock", 0, []dbus.ObjectPath{collection}).Store(&unlocked, &prompt)
 	if err != nil {
 		return err
 	}
 
-	_, v, err := s.handlePrompt(prompt)
+	_, v, err := s.handlePrompt(ctx, prompt)
 	if err != nil {
 		return err
 	}
@@ -130,23 +161,23 @@ func (s *SecretService) Unlock(collection dbus.ObjectPath) error {
 }
 
 // Close closes a secret service dbus session.
-func (s *SecretService) Close(session dbus.BusObject) error {
-	return session.Call(sessionInterface+".Close", 0).Err
+func (s *SecretService) Close(ctx context.Context, session dbus.BusObject) error {
+	return session.CallWithContext(ctx, sessionInterface+".Close", 0).Err
 }
 
 // CreateCollection with the supplied label.
-func (s *SecretService) CreateCollection(label string) (dbus.BusObject, error) {
+func (s *SecretService) CreateCollection(ctx context.Context, label string) (dbus.BusObject, error) {
 	properties := map[string]dbus.Variant{
 		collectionInterface + ".Label": dbus.MakeVariant(label),
 	}
 	var collection, prompt dbus.ObjectPath
-	err := s.object.Call(serviceInterface+".CreateCollection", 0, properties, "").
+	err := s.object.CallWithContext(ctx, serviceInterface+".CreateCollection", 0, properties, "").
 		Store(&collection, &prompt)
 	if err != nil {
 		return nil, err
 	}
 
-	_, v, err := s.handlePrompt(prompt)
+	_, v, err := s.handlePrompt(ctx, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -160,20 +191,20 @@ func (s *SecretService) CreateCollection(label string) (dbus.BusObject, error) {
 
 // CreateItem creates an item in a collection, with label, attributes and a
 // related secret.
-func (s *SecretService) CreateItem(collection dbus.BusObject, label string, attributes map[string]string, secret Secret) error {
+func (s *SecretService) CreateItem(ctx context.Context, collection dbus.BusObject, label string, attributes map[string]string, secret Secret) error {
 	properties := map[string]dbus.Variant{
 		itemInterface + ".Label":      dbus.MakeVariant(label),
 		itemInterface + ".Attributes": dbus.MakeVariant(attributes),
 	}
 
 	var item, prompt dbus.ObjectPath
-	err := collection.Call(collectionInterface+".CreateItem", 0,
+	err := collection.CallWithContext(ctx, collectionInterface+".CreateItem", 0,
 		properties, secret, true).Store(&item, &prompt)
 	if err != nil {
 		return err
 	}
 
-	_, _, err = s.handlePrompt(prompt)
+	_, _, err = s.handlePrompt(ctx, prompt)
 	if err != nil {
 		return err
 	}
@@ -181,10 +212,35 @@ func (s *SecretService) CreateItem(collection dbus.BusObject, label string, attr
 	return nil
 }
 
+// SetSecret replaces the secret of an item.
+func (s *SecretService) SetSecret(ctx context.Context, itemPath dbus.ObjectPath, secret Secret) error {
+	return s.Object(serviceName, itemPath).CallWithContext(ctx, itemInterface+".SetSecret", 0, secret).Err
+}
+
+// SetLabel replaces the label of an item.
+func (s *SecretService) SetLabel(itemPath dbus.ObjectPath, label string) error {
+	return s.Object(serviceName, itemPath).SetProperty(itemInterface+".Label", dbus.MakeVariant(label))
+}
+
+// GetAttributes returns the lookup attributes of an item.
+func (s *SecretService) GetAttributes(itemPath dbus.ObjectPath) (map[string]string, error) {
+	variant, err := s.Object(serviceName, itemPath).GetProperty(itemInterface + ".Attributes")
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, ok := variant.Value().(map[string]string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected attributes of item %s", itemPath)
+	}
+
+	return attributes, nil
+}
+
 // handlePrompt checks if a prompt should be handles and handles it by
 // triggering the prompt and waiting for the Secret service daemon to display
 // the prompt to the user.
-func (s *SecretService) handlePrompt(prompt dbus.ObjectPath) (bool, dbus.Variant, error) {
+func (s *SecretService) handlePrompt(ctx context.Context, prompt dbus.ObjectPath) (bool, dbus.Variant, error) {
 	if prompt != dbus.ObjectPath("/") {
 		err := s.AddMatchSignal(dbus.WithMatchObjectPath(prompt),
 			dbus.WithMatchInterface(promptInterface),
@@ -199,13 +255,20 @@ func (s *SecretService) handlePrompt(prompt dbus.ObjectPath) (bool, dbus.Variant
 
 		promptSignal := make(chan *dbus.Signal, 1)
 		s.Signal(promptSignal)
+		defer s.RemoveSignal(promptSignal)
 
-		err = s.Object(serviceName, prompt).Call(promptInterface+".Prompt", 0, "").Err
+		err = s.Object(serviceName, prompt).CallWithContext(ctx, promptInterface+".Prompt", 0, "").Err
 		if err != nil {
 			return false, dbus.MakeVariant(""), err
 		}
 
-		signal := <-promptSignal
+		var signal *dbus.Signal
+		select {
+		case signal = <-promptSignal:
+		case <-ctx.Done():
+			return false, dbus.MakeVariant(""), fmt.Errorf("%w: %w", ErrPromptCancelled, ctx.Err())
+		}
+
 		switch signal.Name {
 		case promptInterface + ".Completed":
 			dismissed := signal.Body[0].(bool)
@@ -219,9 +282,9 @@ func (s *SecretService) handlePrompt(prompt dbus.ObjectPath) (bool, dbus.Variant
 }
 
 // SearchItems returns a list of items matching the search object.
-func (s *SecretService) SearchItems(collection dbus.BusObject, search interface{}) ([]dbus.ObjectPath, error) {
+func (s *SecretService) SearchItems(ctx context.Context, collection dbus.BusObject, search interface{}) ([]dbus.ObjectPath, error) {
 	var results []dbus.ObjectPath
-	err := collection.Call(collectionInterface+".SearchItems", 0, search).Store(&results)
+	err := collection.CallWithContext(ctx, collectionInterface+".SearchItems", 0, search).Store(&results)
 	if err != nil {
 		return nil, err
 	}
@@ -230,9 +293,9 @@ func (s *SecretService) SearchItems(collection dbus.BusObject, search interface{
 }
 
 // GetSecret gets secret from an item in a given session.
-func (s *SecretService) GetSecret(itemPath dbus.ObjectPath, session dbus.ObjectPath) (*Secret, error) {
+func (s *SecretService) GetSecret(ctx context.Context, itemPath dbus.ObjectPath, session dbus.ObjectPath) (*Secret, error) {
 	var secret Secret
-	err := s.Object(serviceName, itemPath).Call(itemInterface+".GetSecret", 0, session).Store(&secret)
+	err := s.Object(serviceName, itemPath).CallWithContext(ctx, itemInterface+".GetSecret", 0, session).Store(&secret)
 	if err != nil {
 		return nil, err
 	}
@@ -241,14 +304,14 @@ func (s *SecretService) GetSecret(itemPath dbus.ObjectPath, session dbus.ObjectP
 }
 
 // Delete deletes an item from the collection.
-func (s *SecretService) Delete(itemPath dbus.ObjectPath) error {
+func (s *SecretService) Delete(ctx context.Context, itemPath dbus.ObjectPath) error {
 	var prompt dbus.ObjectPath
-	err := s.Object(serviceName, itemPath).Call(itemInterface+".Delete", 0).Store(&prompt)
+	err := s.Object(serviceName, itemPath).CallWithContext(ctx, itemInterface+".Delete", 0).Store(&prompt)
 	if err != nil {
 		return err
 	}
 
-	_, _, err = s.handlePrompt(prompt)
+	_, _, err = s.handlePrompt(ctx, prompt)
 	if err != nil {
 		return err
 	}
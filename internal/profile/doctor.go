@@ -0,0 +1,162 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+type Problem struct {
+	Profile string
+	Problem string
+	Fix     string
+}
+
+func NewDoctor(verbose bool) *Doctor {
+	doctor := Doctor{
+		Verbose: verbose,
+	}
+
+	return &doctor
+}
+
+func (p *Doctor) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "profiledoctor")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	profiles.InitialiseProfilesTree(ctx)
+
+	problems := make([]Problem, 0)
+	for _, name := range profiles.ProfileNames {
+		problems = append(problems, CheckProfile(ctx, profiles, name)...)
+	}
+
+	if len(problems) == 0 {
+		clio.Successf("Checked %d profiles, no problems found", len(profiles.ProfileNames))
+		return nil
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("[%s]\n  problem: %s\n  fix: %s\n\n", problem.Profile, problem.Problem, problem.Fix)
+	}
+
+	return fmt.Errorf("checked %d profiles, found %d problems", len(profiles.ProfileNames), len(problems))
+}
+
+// CheckProfile reports the problems of an initialised profile with a suggested fix
+func CheckProfile(ctx context.Context, profiles *awsprofile.Profiles, name string) []Problem {
+	afcfg := afconfig.NewDefaultConfig()
+	problems := make([]Problem, 0)
+
+	add := func(problem, fix string, args ...any) {
+		problems = append(problems, Problem{Profile: name, Problem: problem, Fix: fmt.Sprintf(fix, args...)})
+	}
+
+	profile, err := profiles.Profile(name)
+	if err != nil {
+		add(err.Error(), "check the AWS config files")
+		return problems
+	}
+
+	raw := profile.RawConfig
+	credentialProcess := fmt.Sprintf("credential_process = %s sso credential-process --profile %s", afcfg.AppName, name)
+
+	if awsprofile.HasCustomSSOPrefix(raw) {
+		err := awsprofile.IsValidCustomProfile(raw)
+		if err != nil {
+			add(err.Error(), "add the missing key to the profile or run '%s sso configure' to generate it again", afcfg.AppName)
+		}
+
+		sessionKey := afcfg.AppNameConfig + "_sso_session"
+		if raw.HasKey(sessionKey) && !profiles.HasSession(raw.Key(sessionKey).Value()) {
+			session := raw.Key(sessionKey).Value()
+			add(fmt.Sprintf("sso session %s does not exist", session),
+				"add a [sso-session %s] section with %s_sso_start_url and %s_sso_region", session, afcfg.AppNameConfig, afcfg.AppNameConfig)
+		}
+
+		if !raw.HasKey("credential_process") {
+			add("missing credential_process", "add '%s' to the profile", credentialProcess)
+		} else {
+			err := awsprofile.ValidateCredentialProcess(raw.Key("credential_process").Value(), name)
+			if err != nil {
+				add(oneLine(err.Error()), "replace it with '%s'", credentialProcess)
+			}
+		}
+	} else if raw.HasKey("source_profile") {
+		source := raw.Key("source_profile").Value()
+		if !profiles.HasProfile(source) {
+			add(fmt.Sprintf("source_profile %s does not exist", source),
+				"create a profile named %s or change source_profile to an existing profile", source)
+		}
+	}
+
+	if len(problems) > 0 {
+		// the loading error is caused by the problems above
+		return problems
+	}
+
+	if profile.LoadingError != nil {
+		msg := profile.LoadingError.Error()
+		switch {
+		case strings.Contains(msg, "cyclic") || strings.Contains(msg, "depth exceeded"):
+			add(oneLine(msg), "remove the cycle from the source_profile chain")
+		case strings.Contains(msg, "source-profile"):
+			add(oneLine(msg), "fix the problems reported for the source profiles of this profile")
+		case strings.Contains(msg, "partial credentials"):
+			add(oneLine(msg), "set both aws_access_key_id and aws_secret_access_key, or remove them")
+		case strings.Contains(msg, "failed to load assume role"):
+			add(oneLine(msg), "make sure the source_profile chain has no cycles and ends in a profile with credentials")
+		default:
+			add(oneLine(msg), "check the profile configuration")
+		}
+		return problems
+	}
+
+	_, err = profile.Region(ctx)
+	if err != nil {
+		add(oneLine(err.Error()), "add 'region = <region>' to the profile")
+	}
+
+	if raw.HasKey(afcfg.AppNameConfig + "_firefox_color") {
+		err := afconfig.ValidateFirefoxColor(raw.Key(afcfg.AppNameConfig + "_firefox_color").Value())
+		if err != nil {
+			add(err.Error(), "change %s_firefox_color to one of the allowed values", afcfg.AppNameConfig)
+		}
+	}
+
+	if raw.HasKey(afcfg.AppNameConfig + "_firefox_icon") {
+		err := afconfig.ValidateFirefoxIcon(raw.Key(afcfg.AppNameConfig + "_firefox_icon").Value())
+		if err != nil {
+			add(err.Error(), "change %s_firefox_icon to one of the allowed values", afcfg.AppNameConfig)
+		}
+	}
+
+	return problems
+}
+
+// oneLine collapses multi line errors, e.g. from the AWS SDK, into a single line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewList(verbose bool) *List {
+	list := List{
+		Verbose: verbose,
+	}
+
+	return &list
+}
+
+func (p *List) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "profilelist")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tACCOUNT\tROLE\tREGION\tDESCRIPTION")
+
+	for _, name := range profiles.ProfileNames {
+		item := NewItem(ctx, profiles, name)
+
+		if item.Error != nil {
+			item.Type = "<error>"
+			clio.Debugf("failed to load profile %s: %s", name, item.Error)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Name, item.Type, item.AccountID, item.Role, item.Region, item.Description)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,66 @@
+package profile
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+type List struct {
+	Verbose bool
+}
+
+type Show struct {
+	Profile string
+	Verbose bool
+}
+
+type Doctor struct {
+	Verbose bool
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:  "profile",
+		Usage: "Inspect and validate AWS profiles",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List all profiles",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					list := NewList(c.Bool("verbose"))
+
+					return list.Execute(c.Context)
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show how a profile is resolved",
+				ArgsUsage: "<profile>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					show := NewShow(c.Args().First(), c.Bool("verbose"))
+
+					return show.Execute(c.Context)
+				},
+			},
+			{
+				Name:  "doctor",
+				Usage: "Check all profiles for problems",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+				},
+				Action: func(c *cli.Context) error {
+					doctor := NewDoctor(c.Bool("verbose"))
+
+					return doctor.Execute(c.Context)
+				},
+			},
+		},
+	}
+
+	return &command
+}
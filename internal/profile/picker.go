@@ -0,0 +1,187 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// default value of the profile flag when AWS_PROFILE is not set
+const unsetProfile = "$AWS_PROFILE"
+
+// commands run by SDKs, kubectl or in the background rather than by the user, their profile does not count
+// for the frecency of the picker
+var backgroundCommands = map[string]bool{
+	"credential-process": true,
+	"get-token":          true,
+	"agent":              true,
+	"serve":              true,
+}
+
+// NewItem describes a profile for the picker
+func NewItem(ctx context.Context, profiles *awsprofile.Profiles, name string) Item {
+	item := Item{Name: name}
+
+	p, err := profiles.LoadInitialisedProfile(ctx, name)
+	if err != nil {
+		item.Error = err
+		return item
+	}
+
+	item.Type = ProfileType(p)
+	item.Description = p.Description()
+	item.Region, _ = p.Region(ctx)
+
+	item.AccountID, item.Role = p.AccountAndRole()
+
+	return item
+}
+
+// ProfileType returns a short description of how credentials of the profile are obtained
+func ProfileType(p *awsprofile.Profile) string {
+	if p.RawConfig != nil && p.RawConfig.HasKey("credential_process") {
+		return "credential_process"
+	}
+
+	switch p.ProfileType {
+	case awsprofile.ProfileTypeSSO:
+		return "SSO"
+	case awsprofile.ProfileTypeIAM:
+		return "IAM"
+	case awsprofile.ProfileTypeCredentialProcess:
+		return "credential_process"
+	case awsprofile.ProfileTypeOIDC:
+		return "OIDC"
+	case awsprofile.ProfileTypeSAML:
+		return "SAML"
+	}
+
+	return p.ProfileType
+}
+
+// Select shows a fuzzy finder with all profiles, most used first, and returns the selected one
+func Select(ctx context.Context) (string, error) {
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return "", err
+	}
+
+	frecent, names := profiles.GetFrecentProfiles()
+	if len(names) == 0 {
+		return "", fmt.Errorf("no profiles found in the AWS config files")
+	}
+
+	items := make([]Item, 0, len(names))
+	for _, name := range names {
+		items = append(items, NewItem(ctx, profiles, name))
+	}
+
+	idx, err := tui("Profiles", items)
+	if err != nil {
+		return "", err
+	}
+
+	selected := items[idx].Name
+	frecent.Update(selected)
+
+	return selected, nil
+}
+
+func isInteractive() bool {
+	// stdout is usually captured, e.g. eval $(aws-fuzzy sso login), the picker is drawn on the terminal anyway
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// Resolve shows the profile picker if the command was invoked without a profile, a profile given to a command
+// the user runs counts as used for the frecency of the picker
+func Resolve(c *cli.Context) error {
+	current := c.String("profile")
+	if current != "" && current != unsetProfile {
+		if c.Command == nil || !backgroundCommands[c.Command.Name] {
+			awsprofile.UpdateFrecencyCache(current)
+		}
+		return nil
+	}
+
+	if !isInteractive() {
+		if current == "" {
+			return fmt.Errorf("missing profile name")
+		}
+		// keep the previous behavior for scripts
+		return nil
+	}
+
+	selected, err := Select(c.Context)
+	if err != nil {
+		return err
+	}
+
+	return c.Set("profile", selected)
+}
+
+// WithPicker wraps the action of every command that has a profile flag so the picker
+// is shown when no profile was given
+func WithPicker(commands []*cli.Command) []*cli.Command {
+	for _, command := range commands {
+		WithPicker(command.Subcommands)
+
+		if command.Action == nil || !hasProfileFlag(command) {
+			continue
+		}
+
+		action := command.Action
+		command.Action = func(c *cli.Context) error {
+			err := Resolve(c)
+			if err != nil {
+				return err
+			}
+
+			return action(c)
+		}
+	}
+
+	return commands
+}
+
+func hasProfileFlag(command *cli.Command) bool {
+	for _, f := range command.Flags {
+		for _, name := range f.Names() {
+			if name == "profile" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormaliseArgs converts a profile flag without a value into an empty value,
+// e.g. 'aws-fuzzy ssh -p' or 'aws-fuzzy exec -p -- cmd', so the picker is shown
+// instead of using the next argument as the profile name
+func NormaliseArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+
+		if arg == "-p" || arg == "--profile" {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+				out = append(out, "--profile=")
+				continue
+			}
+		}
+
+		out = append(out, arg)
+	}
+
+	return out
+}
@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewShow(profile string, verbose bool) *Show {
+	show := Show{
+		Profile: profile,
+		Verbose: verbose,
+	}
+
+	return &show
+}
+
+func (p *Show) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "profileshow")
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	if p.Profile == "" {
+		if !isInteractive() {
+			return fmt.Errorf("missing profile name, e.g. 'aws-fuzzy profile show my-profile'")
+		}
+
+		p.Profile, err = Select(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	profile, err := profiles.LoadInitialisedProfile(ctx, p.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to load profile %s: %w\nrun 'aws-fuzzy profile doctor' for suggested fixes", p.Profile, err)
+	}
+
+	item := NewItem(ctx, profiles, p.Profile)
+
+	fmt.Printf("Name: %s\n", profile.Name)
+	fmt.Printf("File: %s\n", profile.File)
+	fmt.Printf("Type: %s\n", item.Type)
+	fmt.Printf("Account: %s\n", item.AccountID)
+	fmt.Printf("Role: %s\n", item.Role)
+	fmt.Printf("Description: %s\n", item.Description)
+
+	chain := make([]string, 0, len(profile.Parents)+1)
+	for _, parent := range profile.Parents {
+		chain = append(chain, parent.Name)
+	}
+	chain = append(chain, profile.Name)
+	fmt.Printf("Chain: %s\n", strings.Join(chain, " -> "))
+
+	region, source, err := regionSource(ctx, profile)
+	if err != nil {
+		fmt.Printf("Region: <unknown> (%s)\n", err)
+	} else {
+		fmt.Printf("Region: %s (%s)\n", region, source)
+	}
+
+	if profile.ProfileType == awsprofile.ProfileTypeSSO {
+		root := profile
+		if len(profile.Parents) > 0 {
+			root = profile.Parents[0]
+		}
+
+		if session := profile.AWSConfig.SSOSession; session != nil {
+			fmt.Printf("Session: %s\n", session.Name)
+			fmt.Printf("StartURL: %s\n", session.SSOStartURL)
+			fmt.Printf("SSORegion: %s\n", session.SSORegion)
+		} else {
+			fmt.Printf("Session: <none, legacy profile>\n")
+			fmt.Printf("StartURL: %s\n", root.AWSConfig.SSOStartURL)
+			fmt.Printf("SSORegion: %s\n", root.AWSConfig.SSORegion)
+		}
+	}
+
+	if profile.RawConfig.HasKey("credential_process") {
+		fmt.Printf("CredentialProcess: %s\n", profile.RawConfig.Key("credential_process").Value())
+	}
+
+	return nil
+}
+
+// regionSource resolves the region like Profile.Region and also returns where it came from
+func regionSource(ctx context.Context, p *awsprofile.Profile) (string, string, error) {
+	if p.AWSConfig.Region != "" {
+		return p.AWSConfig.Region, fmt.Sprintf("region of profile %s", p.Name), nil
+	}
+
+	if len(p.Parents) > 0 {
+		// Profile.Region uses the direct parent
+		return regionSource(ctx, p.Parents[len(p.Parents)-1])
+	}
+
+	if p.AWSConfig.SSORegion != "" {
+		return p.AWSConfig.SSORegion, fmt.Sprintf("sso region of profile %s", p.Name), nil
+	}
+
+	region, err := p.Region(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	return region, "default region", nil
+}
@@ -0,0 +1,155 @@
+package profile
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/AndreZiviani/fzf-wrapper/v2"
+	"github.com/rivo/tview"
+)
+
+// Item is a profile as displayed by the picker
+type Item struct {
+	Name        string
+	Type        string
+	AccountID   string
+	Role        string
+	Region      string
+	Description string
+	Error       error
+}
+
+func (i Item) PrintName() string {
+	if i.Description != "" {
+		return fmt.Sprintf("%s (%s)", i.Name, i.Description)
+	}
+	return i.Name
+}
+
+func (i Item) PrintDetails() string {
+	output := bytes.NewBufferString("")
+
+	fmt.Fprintf(output, "Name: %s\n", i.Name)
+	fmt.Fprintf(output, "Type: %s\n", i.Type)
+	fmt.Fprintf(output, "Account: %s\n", i.AccountID)
+	fmt.Fprintf(output, "Role: %s\n", i.Role)
+	fmt.Fprintf(output, "Region: %s\n", i.Region)
+	fmt.Fprintf(output, "Description: %s\n", i.Description)
+
+	if i.Error != nil {
+		fmt.Fprintf(output, "Error: %s\n", i.Error)
+	}
+
+	return output.String()
+}
+
+type Tui struct {
+	app             *tview.Application
+	flex            *tview.Flex
+	input           *tview.InputField
+	resourceList    *tview.List
+	resourceDetails *tview.TextView
+	fzf             *fzfwrapper.Wrapper
+	resources       []Item
+	selected        int
+	resourceIdx     []int
+}
+
+type FzfData struct {
+	Resources []Item
+}
+
+func (f FzfData) FzfInputList() []string {
+	out := make([]string, 0, f.FzfInputLen())
+
+	for _, r := range f.Resources {
+		out = append(out, r.PrintDetails())
+	}
+
+	return out
+}
+
+func (f FzfData) FzfInputLen() int {
+	return len(f.Resources)
+}
+
+func (t *Tui) resourceListFunc(id int, text string, secondary string, shortcut rune) {
+	t.resourceDetails.SetText(
+		fmt.Sprintf("%s\n", secondary),
+	)
+}
+
+func (t *Tui) inputFunc(text string) {
+	if text == "" {
+		t.resourceList.Clear()
+		last := len(t.resources) - 1
+		for k, v := range t.resources {
+			t.resourceIdx[last-k] = k
+			t.resourceList.InsertItem(
+				-t.resourceList.GetItemCount()-1,
+				v.PrintName(),
+				v.PrintDetails(),
+				0, nil,
+			)
+		}
+		return
+	}
+
+	t.fzf.SetPattern(text)
+	results, _ := t.fzf.Fuzzy()
+
+	t.resourceList.Clear()
+	t.resourceDetails.Clear()
+
+	last := len(results) - 1
+	for k, v := range results {
+		t.resourceIdx[last-k] = int(v.Item.Index())
+		r := t.resources[v.Item.Index()]
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			tview.TranslateANSI(
+				r.PrintName(),
+			),
+			tview.TranslateANSI(v.HighlightResult()),
+			0, nil,
+		)
+	}
+
+	t.resourceList.SetCurrentItem(-1)
+	t.resourceList.SetOffset(0, 0)
+	boldItem(t.resourceList, t.resourceList.GetCurrentItem())
+}
+
+// tui returns the index of the selected profile
+func tui(title string, resources []Item) (int, error) {
+
+	t := NewTui()
+	t.resourceList.SetTitle(title)
+
+	t.fzf.SetInput(FzfData{Resources: resources})
+	t.resources = resources
+	t.resourceIdx = make([]int, len(t.resources))
+
+	last := len(t.resources) - 1
+
+	for k, v := range t.resources {
+		t.resourceIdx[last-k] = k // reverse order since we are adding items to the beggining of the list
+		t.resourceList.InsertItem(
+			-t.resourceList.GetItemCount()-1,
+			v.PrintName(),
+			v.PrintDetails(),
+			0, nil,
+		)
+	}
+
+	if err := t.app.SetRoot(t.flex, true).SetFocus(t.flex).Run(); err != nil {
+		panic(err)
+	}
+
+	if t.selected < 0 {
+		// user aborted the selection (ctrl+c?)
+		return -1, fmt.Errorf("aborting by user request")
+	}
+
+	return t.selected, nil
+}
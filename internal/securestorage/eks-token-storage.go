@@ -1,6 +1,7 @@
 package securestorage
 
 import (
+	"context"
 	"time"
 
 	"github.com/common-fate/clio"
@@ -27,16 +28,16 @@ type EKSToken struct {
 
 // GetValidEKSToken retrieves a cached EKS token if it exists and is not expired.
 // Returns nil if the token is not found or has expired.
-func (s *EKSTokenSecureStorage) GetValidEKSToken(cacheKey string) (*EKSToken, error) {
+func (s *EKSTokenSecureStorage) GetValidEKSToken(ctx context.Context, cacheKey string) (*EKSToken, error) {
 	var t EKSToken
-	err := s.SecureStorage.Retrieve(cacheKey, &t)
+	err := s.SecureStorage.Retrieve(ctx, cacheKey, &t)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check expiration with a small buffer to avoid edge cases
 	if t.Expiration.Before(time.Now().Add(30 * time.Second)) {
-		s.ClearEKSToken(cacheKey)
+		s.ClearEKSToken(ctx, cacheKey)
 		return nil, nil
 	}
 
@@ -44,16 +45,16 @@ func (s *EKSTokenSecureStorage) GetValidEKSToken(cacheKey string) (*EKSToken, er
 }
 
 // StoreEKSToken caches an EKS token in secure storage.
-func (s *EKSTokenSecureStorage) StoreEKSToken(cacheKey string, token EKSToken) {
-	err := s.SecureStorage.Store(cacheKey, token)
+func (s *EKSTokenSecureStorage) StoreEKSToken(ctx context.Context, cacheKey string, token EKSToken) {
+	err := s.SecureStorage.Store(ctx, cacheKey, token)
 	if err != nil {
 		clio.Debugf("writing EKS token to credentials cache: %s", err.Error())
 	}
 }
 
 // ClearEKSToken removes a cached EKS token from secure storage.
-func (s *EKSTokenSecureStorage) ClearEKSToken(cacheKey string) {
-	err := s.SecureStorage.Clear(cacheKey)
+func (s *EKSTokenSecureStorage) ClearEKSToken(ctx context.Context, cacheKey string) {
+	err := s.SecureStorage.Clear(ctx, cacheKey)
 	if err != nil {
 		clio.Debugf("clearing EKS token from the credentials cache: %s", err.Error())
 	}
@@ -0,0 +1,295 @@
+package securestorage
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// types of the cached entries
+const (
+	EntrySSOToken        = "sso-token"
+	EntryRoleCredentials = "role-credentials"
+	EntrySSORegistration = "sso-registration"
+	EntryIAMCredentials  = "iam-credentials"
+	EntryEKSToken        = "eks-token"
+	EntryMFASession      = "mfa-session"
+	EntryTOTPSecret      = "totp-secret"
+)
+
+// persistentEntryTypes are secrets stored by the user rather than cache, e.g. access keys moved to the keyring
+// by iam import, the keyring may hold the only copy of them
+var persistentEntryTypes = []string{EntryIAMCredentials, EntryTOTPSecret}
+
+// IsPersistent reports whether entries of the type are secrets stored by the user that can not be fetched again
+func IsPersistent(entryType string) bool {
+	for _, t := range persistentEntryTypes {
+		if t == entryType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entry describes a cached secret without its value
+type Entry struct {
+	Type string
+	Key  string
+	// zero when the entry does not expire
+	Expires time.Time
+	// the payload could not be decoded
+	Corrupt bool
+
+	storage SecureStorage
+}
+
+// Expired reports whether the entry expired before t
+func (e Entry) Expired(t time.Time) bool {
+	return !e.Expires.IsZero() && e.Expires.Before(t)
+}
+
+// Clear removes the entry from the keyring
+func (e Entry) Clear(ctx context.Context) error {
+	return e.storage.Clear(ctx, e.Key)
+}
+
+// cachedPayload has the fields of every payload that tell its type and expiry apart
+type cachedPayload struct {
+	// SSOToken
+	AccessToken string    `json:"accessToken"`
+	Expiry      time.Time `json:"expiry"`
+
+	// aws.Credentials, which has no json tags
+	AccessKeyID string
+	CanExpire   bool
+	Expires     time.Time
+
+	// roleCredentials, the versioned payload of aws.Credentials
+	Credentials *cachedPayload `json:"credentials"`
+
+	// ClientRegistration
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt"`
+
+	// EKSToken
+	Expiration time.Time `json:"expiration"`
+}
+
+// cacheStorage is a storage with the types of the entries it holds
+type cacheStorage struct {
+	storage SecureStorage
+	types   []string
+	// describe returns the type and expiry of a decoded payload
+	describe func(p cachedPayload) (string, time.Time)
+}
+
+func credentialsExpiry(p cachedPayload) time.Time {
+	if !p.CanExpire {
+		return time.Time{}
+	}
+	return p.Expires
+}
+
+func cacheStorages() []cacheStorage {
+	return []cacheStorage{
+		{
+			// role credentials were cached by profile name along with the tokens by previous versions
+			storage: NewSecureSSOTokenStorage().SecureStorage,
+			types:   []string{EntrySSOToken, EntryRoleCredentials},
+			describe: func(p cachedPayload) (string, time.Time) {
+				if p.AccessKeyID != "" {
+					return EntryRoleCredentials, credentialsExpiry(p)
+				}
+				return EntrySSOToken, p.Expiry
+			},
+		},
+		{
+			storage: NewSecureRoleCredentialsStorage().SecureStorage,
+			types:   []string{EntryRoleCredentials},
+			describe: func(p cachedPayload) (string, time.Time) {
+				if p.Credentials != nil {
+					return EntryRoleCredentials, credentialsExpiry(*p.Credentials)
+				}
+				return EntryRoleCredentials, credentialsExpiry(p)
+			},
+		},
+		{
+			storage: NewSecureClientRegistrationStorage().SecureStorage,
+			types:   []string{EntrySSORegistration},
+			describe: func(p cachedPayload) (string, time.Time) {
+				return EntrySSORegistration, p.RegistrationExpiresAt
+			},
+		},
+		{
+			storage: NewSecureIAMCredentialStorage().SecureStorage,
+			types:   []string{EntryIAMCredentials},
+			describe: func(p cachedPayload) (string, time.Time) {
+				return EntryIAMCredentials, credentialsExpiry(p)
+			},
+		},
+		{
+			storage: NewSecureEKSTokenStorage().SecureStorage,
+			types:   []string{EntryEKSToken},
+			describe: func(p cachedPayload) (string, time.Time) {
+				return EntryEKSToken, p.Expiration
+			},
+		},
+		{
+			storage: NewSecureMFASessionStorage().SecureStorage,
+			types:   []string{EntryMFASession},
+			describe: func(p cachedPayload) (string, time.Time) {
+				return EntryMFASession, credentialsExpiry(p)
+			},
+		},
+		{
+			// the payload is the secret itself and never expires
+			storage: NewSecureTOTPSecretStorage().SecureStorage,
+			types:   []string{EntryTOTPSecret},
+		},
+	}
+}
+
+// EntryTypes returns the types of the cached entries
+func EntryTypes() []string {
+	types := []string{}
+	for _, cs := range cacheStorages() {
+		types = append(types, cs.types...)
+	}
+
+	return types
+}
+
+// CacheEntryTypes returns the types of the entries that are fetched again when removed
+func CacheEntryTypes() []string {
+	types := []string{}
+	for _, t := range EntryTypes() {
+		if !IsPersistent(t) && !hasAny(types, []string{t}) {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+// entries returns the entries of the storage of the given types, or of every type if none is given.
+// Payloads are only decoded to find their type and expiry, a payload that can not be decoded is
+// returned as a corrupt entry of the first type of the storage.
+func (cs cacheStorage) entries(ctx context.Context, types []string) ([]Entry, error) {
+	keys, err := cs.storage.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, key := range keys {
+		entry := Entry{Type: cs.types[0], Key: key, storage: cs.storage}
+
+		if cs.describe != nil {
+			var raw json.RawMessage
+			var p cachedPayload
+			if err := cs.storage.Retrieve(ctx, key, &raw); err != nil || json.Unmarshal(raw, &p) != nil {
+				entry.Corrupt = true
+			} else {
+				entry.Type, entry.Expires = cs.describe(p)
+			}
+		}
+
+		if len(types) > 0 && !hasAny([]string{entry.Type}, types) {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ListEntries returns the cached entries of the given types, or of every type if none is given
+func ListEntries(ctx context.Context, types ...string) ([]Entry, error) {
+	entries := []Entry{}
+
+	for _, cs := range cacheStorages() {
+		if len(types) > 0 && !hasAny(cs.types, types) {
+			continue
+		}
+
+		found, err := cs.entries(ctx, types)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, found...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, nil
+}
+
+// ClearEntries removes every cached entry of the given types, or of every type if none is given.
+// Storages whose types are all selected are removed at once, the others entry by entry.
+func ClearEntries(ctx context.Context, types ...string) (int, error) {
+	cleared := 0
+
+	for _, cs := range cacheStorages() {
+		if len(types) > 0 && !hasAny(cs.types, types) {
+			continue
+		}
+
+		if len(types) == 0 || hasAll(types, cs.types) {
+			keys, err := cs.storage.Keys(ctx)
+			if err != nil {
+				return cleared, err
+			}
+			if len(keys) == 0 {
+				continue
+			}
+
+			if err := cs.storage.ClearAll(ctx); err != nil {
+				return cleared, err
+			}
+			cleared += len(keys)
+			continue
+		}
+
+		entries, err := cs.entries(ctx, types)
+		if err != nil {
+			return cleared, err
+		}
+		for _, entry := range entries {
+			if err := entry.Clear(ctx); err != nil {
+				return cleared, err
+			}
+			cleared++
+		}
+	}
+
+	return cleared, nil
+}
+
+func hasAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		if !hasAny(values, []string{w}) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,6 +1,10 @@
 package securestorage
 
-import "github.com/aws/aws-sdk-go-v2/aws"
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
 
 type IAMCredentialsSecureStorage struct {
 	SecureStorage SecureStorage
@@ -16,12 +20,12 @@ func NewSecureIAMCredentialStorage() IAMCredentialsSecureStorage {
 	}
 }
 
-func (i *IAMCredentialsSecureStorage) GetCredentials(profile string) (credentials aws.Credentials, err error) {
-	err = i.SecureStorage.Retrieve(profile, &credentials)
+func (i *IAMCredentialsSecureStorage) GetCredentials(ctx context.Context, profile string) (credentials aws.Credentials, err error) {
+	err = i.SecureStorage.Retrieve(ctx, profile, &credentials)
 	return
 }
 
-func (i *IAMCredentialsSecureStorage) StoreCredentials(profile string, credentials aws.Credentials) (err error) {
-	err = i.SecureStorage.Store(profile, &credentials)
+func (i *IAMCredentialsSecureStorage) StoreCredentials(ctx context.Context, profile string, credentials aws.Credentials) (err error) {
+	err = i.SecureStorage.Store(ctx, profile, &credentials)
 	return
 }
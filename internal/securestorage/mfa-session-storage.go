@@ -0,0 +1,59 @@
+package securestorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+)
+
+// mfaSessionExpiryBuffer is how early before expiry we consider an MFA session invalid,
+// leaving time to assume the roles of the chain with it.
+const mfaSessionExpiryBuffer = 5 * time.Minute
+
+type MFASessionSecureStorage struct {
+	SecureStorage SecureStorage
+}
+
+func NewSecureMFASessionStorage() MFASessionSecureStorage {
+	return MFASessionSecureStorage{
+		SecureStorage: SecureStorage{
+			StoragePrefix: "aws-fuzzy",
+			StorageSuffix: "-mfa-sessions",
+			Debug:         false,
+		},
+	}
+}
+
+// GetValidSession retrieves the cached session credentials of an MFA device if they exist and are not expired.
+func (s *MFASessionSecureStorage) GetValidSession(ctx context.Context, mfaSerial string) *aws.Credentials {
+	var creds aws.Credentials
+	err := s.SecureStorage.Retrieve(ctx, mfaSerial, &creds)
+	if err != nil {
+		return nil
+	}
+
+	if !creds.HasKeys() || creds.Expires.Before(time.Now().Add(mfaSessionExpiryBuffer)) {
+		s.ClearSession(ctx, mfaSerial)
+		return nil
+	}
+
+	return &creds
+}
+
+// StoreSession caches the session credentials obtained with an MFA device.
+func (s *MFASessionSecureStorage) StoreSession(ctx context.Context, mfaSerial string, creds aws.Credentials) {
+	err := s.SecureStorage.Store(ctx, mfaSerial, creds)
+	if err != nil {
+		clio.Debugf("writing MFA session to credentials cache: %s", err.Error())
+	}
+}
+
+// ClearSession removes the cached session credentials of an MFA device.
+func (s *MFASessionSecureStorage) ClearSession(ctx context.Context, mfaSerial string) {
+	err := s.SecureStorage.Clear(ctx, mfaSerial)
+	if err != nil {
+		clio.Debugf("clearing MFA session from the credentials cache: %s", err.Error())
+	}
+}
@@ -0,0 +1,138 @@
+package securestorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+)
+
+// roleCredentialsVersion is the version of the payload written by StoreCredentials,
+// payloads of a newer version are ignored instead of being misread
+const roleCredentialsVersion = 1
+
+// RoleCredentialsSecureStorage caches the role credentials of each profile by profile name
+type RoleCredentialsSecureStorage struct {
+	SecureStorage SecureStorage
+	// how long before expiry the cached credentials are considered expired
+	RefreshBuffer time.Duration
+}
+
+func NewSecureRoleCredentialsStorage() RoleCredentialsSecureStorage {
+	refreshBuffer := afconfig.DefaultCredentialsRefreshBuffer
+	if cfg, err := afconfig.NewLoadedConfig(); err == nil {
+		refreshBuffer = cfg.CredentialsRefreshBuffer()
+	}
+
+	return RoleCredentialsSecureStorage{
+		SecureStorage: SecureStorage{
+			StoragePrefix: "aws-fuzzy",
+			StorageSuffix: "-role-credentials",
+			Debug:         false,
+		},
+		RefreshBuffer: refreshBuffer,
+	}
+}
+
+type roleCredentials struct {
+	Version     int             `json:"version"`
+	Credentials aws.Credentials `json:"credentials"`
+}
+
+// GetValidCredentials returns the cached credentials of the profile if they do not expire within the refresh buffer,
+// credentials that do are removed from the cache
+func (s *RoleCredentialsSecureStorage) GetValidCredentials(ctx context.Context, profile string) *aws.Credentials {
+	creds, err := s.GetCredentials(ctx, profile)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			clio.Debugf("error retrieving role credentials of %s from secure storage: %s", profile, err)
+		}
+		return nil
+	}
+
+	if !creds.HasKeys() || (creds.CanExpire && creds.Expires.Before(time.Now().Add(s.RefreshBuffer))) {
+		s.ClearCredentials(ctx, profile)
+		return nil
+	}
+
+	return &creds
+}
+
+// GetCredentials returns the cached credentials of the profile as is, credentials cached by previous versions
+// in the IAM Identity Center token storage are moved to this storage
+func (s *RoleCredentialsSecureStorage) GetCredentials(ctx context.Context, profile string) (aws.Credentials, error) {
+	var payload roleCredentials
+	err := s.SecureStorage.Retrieve(ctx, profile, &payload)
+	if errors.Is(err, ErrNotFound) {
+		return s.migrateLegacyCredentials(ctx, profile)
+	}
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	if payload.Version > roleCredentialsVersion {
+		return aws.Credentials{}, fmt.Errorf("role credentials of %s were cached by a newer version of aws-fuzzy (payload version %d)", profile, payload.Version)
+	}
+
+	return payload.Credentials, nil
+}
+
+// StoreCredentials caches the credentials of the profile
+func (s *RoleCredentialsSecureStorage) StoreCredentials(ctx context.Context, profile string, creds aws.Credentials) {
+	err := s.SecureStorage.Store(ctx, profile, roleCredentials{Version: roleCredentialsVersion, Credentials: creds})
+	if err != nil {
+		clio.Debugf("writing role credentials to credentials cache: %s", err.Error())
+	}
+}
+
+// ClearCredentials removes the cached credentials of the profile, including the ones cached by previous versions
+func (s *RoleCredentialsSecureStorage) ClearCredentials(ctx context.Context, profile string) {
+	err := s.SecureStorage.Clear(ctx, profile)
+	if err != nil {
+		clio.Debugf("clearing role credentials from the credentials cache: %s", err.Error())
+	}
+
+	if _, ok := legacyCredentials(ctx, profile); ok {
+		legacy := NewSecureSSOTokenStorage()
+		legacy.ClearSSOToken(ctx, profile)
+	}
+}
+
+// legacyCredentials returns the credentials cached by previous versions in the IAM Identity Center token storage,
+// which also holds tokens keyed by session name so only payloads with access keys are considered
+func legacyCredentials(ctx context.Context, profile string) (aws.Credentials, bool) {
+	legacy := NewSecureSSOTokenStorage()
+
+	var raw json.RawMessage
+	if err := legacy.SecureStorage.Retrieve(ctx, profile, &raw); err != nil {
+		return aws.Credentials{}, false
+	}
+
+	var creds aws.Credentials
+	if err := json.Unmarshal(raw, &creds); err != nil || creds.AccessKeyID == "" {
+		return aws.Credentials{}, false
+	}
+
+	return creds, true
+}
+
+func (s *RoleCredentialsSecureStorage) migrateLegacyCredentials(ctx context.Context, profile string) (aws.Credentials, error) {
+	creds, ok := legacyCredentials(ctx, profile)
+	if !ok {
+		return aws.Credentials{}, ErrNotFound
+	}
+
+	clio.Debugf("moving the cached role credentials of %s to the role credentials storage", profile)
+
+	s.StoreCredentials(ctx, profile, creds)
+
+	legacy := NewSecureSSOTokenStorage()
+	legacy.ClearSSOToken(ctx, profile)
+
+	return creds, nil
+}
@@ -1,9 +1,14 @@
 package securestorage
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	"github.com/AndreZiviani/aws-fuzzy/internal/keyring"
 )
 
@@ -18,8 +23,52 @@ func (s *SecureStorage) serviceName() string {
 	return s.StoragePrefix + s.StorageSuffix
 }
 
-func (s *SecureStorage) Retrieve(key string, target any) error {
-	val, err := keyring.Get(s.serviceName(), key)
+// configureKeyring selects the keyring backend of the aws-fuzzy config once, before the first access
+var configureKeyring = sync.OnceValue(func() error {
+	cfg, err := afconfig.NewLoadedConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Keyring == nil {
+		return nil
+	}
+
+	fileDir, err := cfg.KeyringFileDir()
+	if err != nil {
+		return err
+	}
+
+	kcfg := keyring.Config{FileDir: fileDir}
+	if cfg.Keyring.Backend != nil {
+		kcfg.Backend = *cfg.Keyring.Backend
+	}
+	if cfg.Keyring.LibSecretCollectionName != nil {
+		kcfg.LibSecretCollectionName = *cfg.Keyring.LibSecretCollectionName
+	}
+	if cfg.Keyring.FilePassphraseFile != nil {
+		kcfg.FilePassphraseFile = *cfg.Keyring.FilePassphraseFile
+	}
+	if cfg.Keyring.PassPrefix != nil {
+		kcfg.PassPrefix = *cfg.Keyring.PassPrefix
+	}
+	if cfg.Keyring.Timeout != nil {
+		timeout, err := time.ParseDuration(*cfg.Keyring.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid keyring timeout '%s', %w", *cfg.Keyring.Timeout, err)
+		}
+		kcfg.Timeout = timeout
+	}
+
+	return keyring.Configure(kcfg)
+})
+
+func (s *SecureStorage) Retrieve(ctx context.Context, key string, target any) error {
+	if err := configureKeyring(); err != nil {
+		return err
+	}
+
+	val, err := keyring.Get(ctx, s.serviceName(), key)
 	if err != nil {
 		if errors.Is(err, keyring.ErrKeyringNotFound) {
 			return ErrNotFound
@@ -29,20 +78,46 @@ func (s *SecureStorage) Retrieve(key string, target any) error {
 	return json.Unmarshal([]byte(val), target)
 }
 
-func (s *SecureStorage) Store(key string, payload any) error {
+func (s *SecureStorage) Store(ctx context.Context, key string, payload any) error {
+	if err := configureKeyring(); err != nil {
+		return err
+	}
+
 	b, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	return keyring.Set(s.serviceName(), key, string(b))
+	return keyring.Set(ctx, s.serviceName(), key, string(b))
 }
 
-func (s *SecureStorage) Clear(key string) error {
-	err := keyring.Delete(s.serviceName(), key)
+func (s *SecureStorage) Clear(ctx context.Context, key string) error {
+	if err := configureKeyring(); err != nil {
+		return err
+	}
+
+	err := keyring.Delete(ctx, s.serviceName(), key)
 	if errors.Is(err, keyring.ErrKeyringNotFound) {
 		return nil
 	}
 	return err
 }
 
+// Keys returns the keys of this storage instance, the secrets are not read
+func (s *SecureStorage) Keys(ctx context.Context) ([]string, error) {
+	if err := configureKeyring(); err != nil {
+		return nil, err
+	}
+
+	return keyring.List(ctx, s.serviceName())
+}
+
+// ClearAll removes every key of this storage instance
+func (s *SecureStorage) ClearAll(ctx context.Context) error {
+	if err := configureKeyring(); err != nil {
+		return err
+	}
+
+	return keyring.DeleteAll(ctx, s.serviceName())
+}
+
 var ErrNotFound = errors.New("key not found in secure storage")
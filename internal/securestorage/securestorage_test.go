@@ -0,0 +1,196 @@
+package securestorage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/keyring"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// useMemoryKeyring stores the secrets of the test in memory instead of the keyring of the OS
+func useMemoryKeyring(t *testing.T) {
+	t.Helper()
+
+	// the aws-fuzzy config is created in the home directory when it is loaded
+	t.Setenv("HOME", t.TempDir())
+
+	configure := configureKeyring
+	configureKeyring = func() error { return nil }
+	restore := keyring.SetProvider(keyring.NewMemoryKeyring())
+
+	t.Cleanup(func() {
+		restore()
+		configureKeyring = configure
+	})
+}
+
+func TestSecureStorage(t *testing.T) {
+	useMemoryKeyring(t)
+	ctx := context.Background()
+
+	s := SecureStorage{StoragePrefix: "aws-fuzzy", StorageSuffix: "-test"}
+
+	var token EKSToken
+	if err := s.Retrieve(ctx, "dev", &token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Store(ctx, "dev", EKSToken{Token: "token"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Retrieve(ctx, "dev", &token); err != nil || token.Token != "token" {
+		t.Errorf("got %+v, %v", token, err)
+	}
+
+	keys, err := s.Keys(ctx)
+	if err != nil || len(keys) != 1 || keys[0] != "dev" {
+		t.Errorf("got %v, %v, want [dev]", keys, err)
+	}
+
+	if err := s.Clear(ctx, "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// clearing a missing key is not an error
+	if err := s.Clear(ctx, "dev"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRoleCredentialsMigration(t *testing.T) {
+	useMemoryKeyring(t)
+	ctx := context.Background()
+
+	creds := aws.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token", CanExpire: true, Expires: time.Now().Add(time.Hour)}
+
+	// previous versions cached role credentials by profile name along with the tokens keyed by session name
+	legacy := NewSecureSSOTokenStorage()
+	legacy.StoreSSOToken(ctx, "my-session", SSOToken{AccessToken: "access-token", Expiry: time.Now().Add(time.Hour)})
+	if err := legacy.SecureStorage.Store(ctx, "dev", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewSecureRoleCredentialsStorage()
+
+	got := store.GetValidCredentials(ctx, "dev")
+	if got == nil || got.AccessKeyID != creds.AccessKeyID {
+		t.Fatalf("got %+v, want the legacy credentials", got)
+	}
+	if _, err := legacy.GetSSOToken(ctx, "dev"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the legacy credentials to be removed, got %v", err)
+	}
+	if got := store.GetValidCredentials(ctx, "dev"); got == nil {
+		t.Error("expected the credentials to be moved to the role credentials storage")
+	}
+
+	// tokens are never read as credentials
+	if got := store.GetValidCredentials(ctx, "my-session"); got != nil {
+		t.Errorf("got %+v for a token", got)
+	}
+	if _, err := legacy.GetSSOToken(ctx, "my-session"); err != nil {
+		t.Errorf("expected the token to be kept, got %v", err)
+	}
+}
+
+func TestRoleCredentialsRefreshBuffer(t *testing.T) {
+	useMemoryKeyring(t)
+	ctx := context.Background()
+
+	store := NewSecureRoleCredentialsStorage()
+	store.RefreshBuffer = 10 * time.Minute
+
+	store.StoreCredentials(ctx, "soon", aws.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", CanExpire: true, Expires: time.Now().Add(5 * time.Minute)})
+	store.StoreCredentials(ctx, "later", aws.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", CanExpire: true, Expires: time.Now().Add(time.Hour)})
+
+	if got := store.GetValidCredentials(ctx, "soon"); got != nil {
+		t.Errorf("got %+v, want credentials that expire within the buffer to be discarded", got)
+	}
+	if _, err := store.GetCredentials(ctx, "soon"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the expiring credentials to be removed, got %v", err)
+	}
+	if got := store.GetValidCredentials(ctx, "later"); got == nil {
+		t.Error("expected valid credentials")
+	}
+
+	if err := store.SecureStorage.Store(ctx, "future", roleCredentials{Version: roleCredentialsVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetCredentials(ctx, "future"); err == nil {
+		t.Error("expected an error for a payload of a newer version")
+	}
+}
+
+func TestEntries(t *testing.T) {
+	useMemoryKeyring(t)
+	ctx := context.Background()
+
+	tokens := NewSecureSSOTokenStorage()
+	tokens.StoreSSOToken(ctx, "my-session", SSOToken{AccessToken: "access-token", Expiry: time.Now().Add(time.Hour)})
+
+	roles := NewSecureRoleCredentialsStorage()
+	roles.StoreCredentials(ctx, "dev", aws.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", CanExpire: true, Expires: time.Now().Add(-time.Hour)})
+
+	eks := NewSecureEKSTokenStorage()
+	eks.StoreEKSToken(ctx, "dev:cluster", EKSToken{Token: "token", Expiration: time.Now().Add(time.Minute)})
+
+	totp := NewSecureTOTPSecretStorage()
+	if err := totp.StoreSecret(ctx, "arn:aws:iam::123456789012:mfa/user", "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatal(err)
+	}
+
+	mfa := NewSecureMFASessionStorage()
+	if err := mfa.SecureStorage.Store(ctx, "corrupt", "not credentials"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ListEntries(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"my-session":                         EntrySSOToken,
+		"dev":                                EntryRoleCredentials,
+		"dev:cluster":                        EntryEKSToken,
+		"arn:aws:iam::123456789012:mfa/user": EntryTOTPSecret,
+		"corrupt":                            EntryMFASession,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if want[entry.Key] != entry.Type {
+			t.Errorf("%s: got type %s, want %s", entry.Key, entry.Type, want[entry.Key])
+		}
+		if entry.Corrupt != (entry.Key == "corrupt") {
+			t.Errorf("%s: got corrupt %v", entry.Key, entry.Corrupt)
+		}
+		if entry.Expired(now) != (entry.Key == "dev") {
+			t.Errorf("%s: got expired %v", entry.Key, entry.Expired(now))
+		}
+	}
+
+	cleared, err := ClearEntries(ctx, EntrySSOToken)
+	if err != nil || cleared != 1 {
+		t.Errorf("got %d, %v, want 1 entry cleared", cleared, err)
+	}
+
+	// the TOTP secret is not cache and is kept
+	cleared, err = ClearEntries(ctx, CacheEntryTypes()...)
+	if err != nil || cleared != 3 {
+		t.Errorf("got %d, %v, want 3 entries cleared", cleared, err)
+	}
+
+	cleared, err = ClearEntries(ctx)
+	if err != nil || cleared != 1 {
+		t.Errorf("got %d, %v, want 1 entry cleared", cleared, err)
+	}
+
+	if entries, _ := ListEntries(ctx); len(entries) != 0 {
+		t.Errorf("got %+v, want no entries", entries)
+	}
+}
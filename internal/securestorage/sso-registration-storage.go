@@ -1,6 +1,7 @@
 package securestorage
 
 import (
+	"context"
 	"time"
 
 	"github.com/common-fate/clio"
@@ -36,32 +37,43 @@ func NewSecureClientRegistrationStorage() ClientRegistrationSecureStorage {
 }
 
 // GetValidRegistration retrieves a cached client registration if it exists and is not expired.
-func (s *ClientRegistrationSecureStorage) GetValidRegistration(key string) *ClientRegistration {
+func (s *ClientRegistrationSecureStorage) GetValidRegistration(ctx context.Context, key string) *ClientRegistration {
 	var r ClientRegistration
-	err := s.SecureStorage.Retrieve(key, &r)
+	err := s.SecureStorage.Retrieve(ctx, key, &r)
 	if err != nil {
 		return nil
 	}
 
 	if r.RegistrationExpiresAt.Before(time.Now().Add(registrationExpiryBuffer)) {
-		s.ClearRegistration(key)
+		s.ClearRegistration(ctx, key)
 		return nil
 	}
 
 	return &r
 }
 
+// GetRegistration returns the cached client registration as is, even if it is expired
+func (s *ClientRegistrationSecureStorage) GetRegistration(ctx context.Context, key string) (*ClientRegistration, error) {
+	var r ClientRegistration
+	err := s.SecureStorage.Retrieve(ctx, key, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 // StoreRegistration caches a client registration in secure storage.
-func (s *ClientRegistrationSecureStorage) StoreRegistration(key string, reg ClientRegistration) {
-	err := s.SecureStorage.Store(key, reg)
+func (s *ClientRegistrationSecureStorage) StoreRegistration(ctx context.Context, key string, reg ClientRegistration) {
+	err := s.SecureStorage.Store(ctx, key, reg)
 	if err != nil {
 		clio.Debugf("writing SSO client registration to cache: %s", err.Error())
 	}
 }
 
 // ClearRegistration removes a cached client registration from secure storage.
-func (s *ClientRegistrationSecureStorage) ClearRegistration(key string) {
-	err := s.SecureStorage.Clear(key)
+func (s *ClientRegistrationSecureStorage) ClearRegistration(ctx context.Context, key string) {
+	err := s.SecureStorage.Clear(ctx, key)
 	if err != nil {
 		clio.Debugf("clearing SSO client registration from cache: %s", err.Error())
 	}
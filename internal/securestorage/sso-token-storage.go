@@ -34,6 +34,8 @@ type SSOToken struct {
 	RegistrationExpiresAt time.Time `json:"registrationExpiresAt,omitempty"`
 	Region                string    `json:"region,omitempty"`
 	RefreshToken          *string   `json:"refreshToken,omitempty"`
+	// when the portal session was started, refreshing the access token does not extend it
+	IssuedAt time.Time `json:"issuedAt,omitempty"`
 }
 
 const (
@@ -49,7 +51,7 @@ const (
 // It returns nil if no token was found, or if it is expired and cannot be refreshed.
 func (s *SSOTokensSecureStorage) GetValidSSOToken(ctx context.Context, profileKey string) *SSOToken {
 	var t SSOToken
-	err := s.SecureStorage.Retrieve(profileKey, &t)
+	err := s.SecureStorage.Retrieve(ctx, profileKey, &t)
 	if err != nil {
 		if !errors.Is(err, ErrNotFound) {
 			clio.Warnf("error retrieving IAM Identity Center token from secure storage: %s", err.Error())
@@ -134,17 +136,29 @@ func (s *SSOTokensSecureStorage) GetValidSSOToken(ctx context.Context, profileKe
 		RegistrationExpiresAt: t.RegistrationExpiresAt,
 		RefreshToken:          res.RefreshToken,
 		Region:                t.Region,
+		IssuedAt:              t.IssuedAt,
 	}
 
 	// save the refreshed token to secure storage
-	s.StoreSSOToken(profileKey, newToken)
+	s.StoreSSOToken(ctx, profileKey, newToken)
 
 	return &newToken
 }
 
+// GetSSOToken returns the cached token as is, without refreshing or discarding it when it is expired
+func (s *SSOTokensSecureStorage) GetSSOToken(ctx context.Context, profileKey string) (*SSOToken, error) {
+	var t SSOToken
+	err := s.SecureStorage.Retrieve(ctx, profileKey, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
 // Attempts to store the token, any errors will be logged to debug logging
-func (s *SSOTokensSecureStorage) StoreSSOToken(profileKey string, ssoTokenValue SSOToken) {
-	err := s.SecureStorage.Store(profileKey, ssoTokenValue)
+func (s *SSOTokensSecureStorage) StoreSSOToken(ctx context.Context, profileKey string, ssoTokenValue SSOToken) {
+	err := s.SecureStorage.Store(ctx, profileKey, ssoTokenValue)
 	if err != nil {
 		clio.Debugf("writing sso token to credentials cache: %s", err.Error())
 	}
@@ -152,8 +166,8 @@ func (s *SSOTokensSecureStorage) StoreSSOToken(profileKey string, ssoTokenValue
 }
 
 // Attempts to clear the token, any errors will be logged to debug logging
-func (s *SSOTokensSecureStorage) ClearSSOToken(profileKey string) {
-	err := s.SecureStorage.Clear(profileKey)
+func (s *SSOTokensSecureStorage) ClearSSOToken(ctx context.Context, profileKey string) {
+	err := s.SecureStorage.Clear(ctx, profileKey)
 	if err != nil {
 		clio.Debugf("clearing sso token from the credentials cache: %s", err.Error())
 	}
@@ -0,0 +1,33 @@
+package securestorage
+
+import "context"
+
+type TOTPSecretSecureStorage struct {
+	SecureStorage SecureStorage
+}
+
+func NewSecureTOTPSecretStorage() TOTPSecretSecureStorage {
+	return TOTPSecretSecureStorage{
+		SecureStorage: SecureStorage{
+			StoragePrefix: "aws-fuzzy",
+			StorageSuffix: "-totp-secrets",
+			Debug:         false,
+		},
+	}
+}
+
+// GetSecret retrieves the base32 TOTP secret of an MFA device.
+func (s *TOTPSecretSecureStorage) GetSecret(ctx context.Context, mfaSerial string) (secret string, err error) {
+	err = s.SecureStorage.Retrieve(ctx, mfaSerial, &secret)
+	return
+}
+
+// StoreSecret stores the base32 TOTP secret of an MFA device.
+func (s *TOTPSecretSecureStorage) StoreSecret(ctx context.Context, mfaSerial string, secret string) error {
+	return s.SecureStorage.Store(ctx, mfaSerial, secret)
+}
+
+// ClearSecret removes the TOTP secret of an MFA device.
+func (s *TOTPSecretSecureStorage) ClearSecret(ctx context.Context, mfaSerial string) error {
+	return s.SecureStorage.Clear(ctx, mfaSerial)
+}
@@ -0,0 +1,240 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+)
+
+const (
+	imdsTokenHeader    = "X-aws-ec2-metadata-token"
+	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	imdsMaxTokenTTL    = 21600
+)
+
+// ecsCredentials is the response expected by the SDKs from AWS_CONTAINER_CREDENTIALS_FULL_URI
+type ecsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// imdsCredentials is the response of the IMDS security-credentials endpoint
+type imdsCredentials struct {
+	Code            string `json:"Code"`
+	LastUpdated     string `json:"LastUpdated"`
+	Type            string `json:"Type"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+type imdsTokens struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+}
+
+func newImdsTokens() *imdsTokens {
+	return &imdsTokens{tokens: make(map[string]time.Time)}
+}
+
+func (t *imdsTokens) issue(ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, expires := range t.tokens {
+		if now.After(expires) {
+			delete(t.tokens, k)
+		}
+	}
+	t.tokens[token] = now.Add(ttl)
+
+	return token, nil
+}
+
+func (t *imdsTokens) valid(token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expires, ok := t.tokens[token]
+
+	return ok && time.Now().Before(expires)
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /creds", s.authorized(s.ecsCredentialsHandler))
+	mux.HandleFunc("PUT /profile", s.authorized(s.profileHandler))
+
+	if s.imds != nil {
+		mux.HandleFunc("PUT /latest/api/token", s.imdsTokenHandler)
+		mux.HandleFunc("GET /latest/meta-data/iam/security-credentials/", s.imdsAuthorized(s.imdsRoleHandler))
+		mux.HandleFunc("GET /latest/meta-data/iam/security-credentials/{role}", s.imdsAuthorized(s.imdsCredentialsHandler))
+		mux.HandleFunc("GET /latest/meta-data/placement/region", s.imdsAuthorized(s.imdsRegionHandler))
+	}
+
+	return mux
+}
+
+func (s *server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *server) imdsAuthorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.imds.valid(r.Header.Get(imdsTokenHeader)) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *server) ecsCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	creds, err := s.credentials(r.Context())
+	if err != nil {
+		clio.Errorf("failed to get credentials: %s", err)
+		http.Error(w, "failed to get credentials", http.StatusInternalServerError)
+		return
+	}
+
+	out := ecsCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		Token:           creds.SessionToken,
+	}
+	if creds.CanExpire {
+		out.Expiration = creds.Expires.UTC().Format(time.RFC3339)
+	}
+
+	writeJson(w, out)
+}
+
+func (s *server) profileHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	profile := strings.TrimSpace(string(body))
+	if profile == "" {
+		http.Error(w, "missing profile name", http.StatusBadRequest)
+		return
+	}
+
+	err = s.switchProfile(r.Context(), profile)
+	if err != nil {
+		clio.Errorf("failed to switch to profile %s: %s", profile, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clio.Successf("Now serving credentials for profile %s", profile)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) imdsTokenHandler(w http.ResponseWriter, r *http.Request) {
+	// like the real IMDS, refuse requests that went through a proxy
+	if r.Header.Get("X-Forwarded-For") != "" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	ttl, err := strconv.Atoi(r.Header.Get(imdsTokenTTLHeader))
+	if err != nil || ttl < 1 || ttl > imdsMaxTokenTTL {
+		http.Error(w, "invalid token ttl", http.StatusBadRequest)
+		return
+	}
+
+	token, err := s.imds.issue(time.Duration(ttl) * time.Second)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(imdsTokenTTLHeader, strconv.Itoa(ttl))
+	_, _ = io.WriteString(w, token)
+}
+
+// imdsRoleHandler lists the "instance role", we use the profile name
+func (s *server) imdsRoleHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.WriteString(w, s.currentProfile())
+}
+
+func (s *server) imdsCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("role") != s.currentProfile() {
+		http.NotFound(w, r)
+		return
+	}
+
+	creds, err := s.credentials(r.Context())
+	if err != nil {
+		clio.Errorf("failed to get credentials: %s", err)
+		http.Error(w, "failed to get credentials", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, newImdsCredentials(creds))
+}
+
+func (s *server) imdsRegionHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	region := s.region
+	s.mu.Unlock()
+
+	if region == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	_, _ = io.WriteString(w, region)
+}
+
+func newImdsCredentials(creds *aws.Credentials) imdsCredentials {
+	// IMDS always returns an expiration, long lived credentials are re-read every hour
+	expires := time.Now().Add(time.Hour)
+	if creds.CanExpire {
+		expires = creds.Expires
+	}
+
+	return imdsCredentials{
+		Code:            "Success",
+		LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		Token:           creds.SessionToken,
+		Expiration:      expires.UTC().Format(time.RFC3339),
+	}
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,54 @@
+package serve
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+type Serve struct {
+	Profile     string
+	Host        string
+	Port        int
+	EnvFile     string
+	Imds        bool
+	AllowRemote bool
+	Verbose     bool
+}
+
+func Command() *cli.Command {
+	command := cli.Command{
+		Name:  "serve",
+		Usage: "Serve credentials through a local ECS container credentials (and optionally IMDSv2) endpoint",
+		Description: "Credentials are refreshed automatically before they expire.\n" +
+			"Switch profiles while running by typing a profile name followed by enter,\n" +
+			"or with an authenticated request: curl -X PUT -H \"Authorization: $AWS_CONTAINER_AUTHORIZATION_TOKEN\" -d <profile> http://127.0.0.1:<port>/profile\n\n" +
+			"The server keeps running, so the export lines it prints can not be eval'd, copy them into the shell of the tools\n" +
+			"or write them to a file with --env-file and source it.\n" +
+			"Containers can not use the container credentials endpoint of another host, SDKs only accept it on a loopback address,\n" +
+			"listen on --host 0.0.0.0 with --imds --allow-remote and set AWS_EC2_METADATA_SERVICE_ENDPOINT=http://host.docker.internal:<port>/ instead.\n" +
+			"The IMDS endpoint has no authentication, anyone that can reach the port gets the credentials of the profile,\n" +
+			"so --imds on an address other than loopback is refused without --allow-remote.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+			&cli.StringFlag{Name: "host", Usage: "Address to listen on, e.g. 0.0.0.0 to be reachable from containers", Value: "127.0.0.1", EnvVars: []string{"AWSFUZZY_SERVE_HOST"}},
+			&cli.IntFlag{Name: "port", Usage: "Port to listen on, a random port is used by default", EnvVars: []string{"AWSFUZZY_SERVE_PORT"}},
+			&cli.StringFlag{Name: "env-file", Usage: "Also write the export lines to this file, it is removed when the server stops"},
+			&cli.BoolFlag{Name: "imds", Usage: "Also serve credentials through an IMDSv2 compatible endpoint"},
+			&cli.BoolFlag{Name: "allow-remote", Usage: "Allow --imds on an address other than loopback, anyone that can reach the port gets credentials"},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+		},
+		Action: func(c *cli.Context) error {
+			serve := New(c.String("profile"),
+				c.String("host"),
+				c.Int("port"),
+				c.String("env-file"),
+				c.Bool("imds"),
+				c.Bool("allow-remote"),
+				c.Bool("verbose"),
+			)
+
+			return serve.Execute(c.Context)
+		},
+	}
+
+	return &command
+}
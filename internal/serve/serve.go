@@ -0,0 +1,290 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"golang.org/x/sync/singleflight"
+)
+
+// credentials expiring sooner than this are refreshed before being served
+const refreshBuffer = 5 * time.Minute
+
+type server struct {
+	token string
+	imds  *imdsTokens
+	// concurrent requests share one refresh of each profile, it may open the browser or ask for an MFA token
+	refresh singleflight.Group
+	mu      sync.Mutex
+	profile string
+	region  string
+	creds   *aws.Credentials
+}
+
+func New(profile, host string, port int, envFile string, imds, allowRemote, verbose bool) *Serve {
+	serve := Serve{
+		Profile:     profile,
+		Host:        host,
+		Port:        port,
+		EnvFile:     envFile,
+		Imds:        imds,
+		AllowRemote: allowRemote,
+		Verbose:     verbose,
+	}
+
+	return &serve
+}
+
+func (p *Serve) Execute(ctx context.Context) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "serve")
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	if p.Imds && !p.AllowRemote && !isLoopbackHost(p.Host) {
+		return fmt.Errorf("the IMDS endpoint has no authentication, anyone that can reach %s would get credentials, use --allow-remote to listen on it anyway", p.Host)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	s := &server{token: token}
+	if p.Imds {
+		s.imds = newImdsTokens()
+	}
+
+	// login before starting the server so any interactive authentication happens right away
+	err = s.switchProfile(ctx, p.Profile)
+	if err != nil {
+		return err
+	}
+
+	span.Finish()
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(p.Host, strconv.Itoa(p.Port)))
+	if err != nil {
+		return err
+	}
+
+	addr := listener.Addr().(*net.TCPAddr)
+	endpoint := endpointURL(addr)
+
+	if !addr.IP.IsLoopback() {
+		if p.Imds {
+			clio.Warnf("Listening on %s, anyone that can reach it gets credentials through the IMDS endpoint", addr)
+		} else {
+			clio.Warnf("Listening on %s, SDKs only use the container credentials endpoint on a loopback address, use --imds to serve containers", addr)
+		}
+	}
+
+	srv := &http.Server{
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	go s.readProfiles(ctx, os.Stdin)
+
+	exports := exportLines(endpoint, token, p.Imds)
+	fmt.Print(exports)
+
+	if p.EnvFile != "" {
+		// the token is a secret, only the user can read it
+		err = os.WriteFile(p.EnvFile, []byte(exports), 0600)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.Remove(p.EnvFile) }()
+	}
+
+	clio.Infof("Serving credentials for profile %s on %s, type a profile name and press enter to switch profiles", s.currentProfile(), endpoint)
+
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// isLoopbackHost reports whether the listen address only accepts connections from this host
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// endpointURL returns the URL the tools use to reach the server, a wildcard address is reached on the loopback address
+func endpointURL(addr *net.TCPAddr) string {
+	host := addr.IP.String()
+	if addr.IP.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	return fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(addr.Port)))
+}
+
+// exportLines returns the environment variables the tools need as shell export lines
+func exportLines(endpoint, token string, imds bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "export AWS_CONTAINER_CREDENTIALS_FULL_URI='%s/creds'\n", endpoint)
+	fmt.Fprintf(&b, "export AWS_CONTAINER_AUTHORIZATION_TOKEN='%s'\n", token)
+	if imds {
+		fmt.Fprintf(&b, "export AWS_EC2_METADATA_SERVICE_ENDPOINT='%s/'\n", endpoint)
+	}
+
+	return b.String()
+}
+
+// readProfiles switches to each profile name read from r, one per line
+func (s *server) readProfiles(ctx context.Context, r *os.File) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		profile := strings.TrimSpace(scanner.Text())
+		if profile == "" {
+			continue
+		}
+
+		err := s.switchProfile(ctx, profile)
+		if err != nil {
+			clio.Errorf("failed to switch to profile %s: %s", profile, err)
+			continue
+		}
+
+		clio.Successf("Now serving credentials for profile %s", profile)
+	}
+}
+
+func (s *server) currentProfile() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.profile
+}
+
+// switchProfile fetches credentials for profile and starts serving them
+func (s *server) switchProfile(ctx context.Context, profile string) error {
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	if !profiles.HasProfile(profile) {
+		return fmt.Errorf("profile %s not found", profile)
+	}
+
+	login := sso.Login{Profile: profile}
+
+	creds, err := login.GetCredentialsValidFor(ctx, refreshBuffer)
+	if err != nil {
+		return err
+	}
+
+	region := ""
+	p, err := login.GetProfile(profile)
+	if err == nil {
+		region, _ = p.Region(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profile = profile
+	s.region = region
+	s.creds = creds
+
+	return nil
+}
+
+// credentials returns valid credentials for the current profile, refreshing them if needed
+func (s *server) credentials(ctx context.Context) (*aws.Credentials, error) {
+	s.mu.Lock()
+	profile, creds := s.profile, s.creds
+	s.mu.Unlock()
+
+	if creds != nil && !expiresSoon(creds) {
+		return creds, nil
+	}
+
+	// the lock is not held during the refresh so other requests and profile switches are not blocked by it,
+	// the refresh is not canceled with the request that started it as other requests may be waiting for it
+	refreshCtx := context.WithoutCancel(ctx)
+	v, err, _ := s.refresh.Do(profile, func() (any, error) {
+		clio.Debugf("refreshing credentials for profile %s", profile)
+
+		login := sso.Login{Profile: profile}
+
+		return login.GetCredentialsValidFor(refreshCtx, refreshBuffer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	creds = v.(*aws.Credentials)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the profile may have been switched during the refresh
+	if s.profile == profile {
+		s.creds = creds
+	}
+
+	return creds, nil
+}
+
+func expiresSoon(creds *aws.Credentials) bool {
+	return creds.CanExpire && time.Now().Add(refreshBuffer).After(creds.Expires)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,58 @@
+package serve
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		addr *net.TCPAddr
+		want string
+	}{
+		{&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 41231}, "http://127.0.0.1:41231"},
+		{&net.TCPAddr{IP: net.IPv4zero, Port: 41231}, "http://127.0.0.1:41231"},
+		{&net.TCPAddr{IP: net.IPv6unspecified, Port: 41231}, "http://127.0.0.1:41231"},
+		{&net.TCPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 41231}, "http://192.168.1.10:41231"},
+	}
+
+	for _, tt := range tests {
+		if got := endpointURL(tt.addr); got != tt.want {
+			t.Errorf("endpointURL(%s) = %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":    true,
+		"::1":          true,
+		"localhost":    true,
+		"":             false,
+		"0.0.0.0":      false,
+		"192.168.1.10": false,
+		"example.com":  false,
+	}
+
+	for host, want := range tests {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestExportLines(t *testing.T) {
+	lines := exportLines("http://127.0.0.1:41231", "token", false)
+	if strings.Contains(lines, "AWS_EC2_METADATA_SERVICE_ENDPOINT") {
+		t.Errorf("got IMDS endpoint without --imds: %s", lines)
+	}
+
+	lines = exportLines("http://127.0.0.1:41231", "token", true)
+	want := "export AWS_CONTAINER_CREDENTIALS_FULL_URI='http://127.0.0.1:41231/creds'\n" +
+		"export AWS_CONTAINER_AUTHORIZATION_TOKEN='token'\n" +
+		"export AWS_EC2_METADATA_SERVICE_ENDPOINT='http://127.0.0.1:41231/'\n"
+	if lines != want {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
@@ -8,6 +8,7 @@ type Ssh struct {
 	Profile string
 	User    string
 	Key     string
+	region  string
 }
 
 func Command() *cli.Command {
@@ -56,6 +56,9 @@ func (p *Ssh) GetInstances(ctx context.Context) (*ec2.DescribeInstancesOutput, e
 		return nil, err
 	}
 
+	// used to open the selected instance in the console
+	p.region = cfg.Region
+
 	spanDescribeInstances, ctx := opentracing.StartSpanFromContext(ctx, "ec2describe")
 	ec2client := ec2.NewFromConfig(cfg)
 
@@ -103,11 +106,15 @@ func (p *Ssh) Execute(ctx context.Context) error {
 
 	span.Finish()
 
-	instance, err := tui(instances)
+	instance, console, err := tui(instances)
 	if err != nil {
 		return err
 	}
 
+	if console {
+		return sso.OpenResource(ctx, p.Profile, p.region, sso.EC2InstanceArn(p.region, instance.OwnerId, aws.ToString(instance.InstanceId)))
+	}
+
 	p.DoSsh(aws.ToString(instance.PrivateIpAddress))
 	return nil
 }
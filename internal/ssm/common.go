@@ -50,6 +50,10 @@ func GetInstances(ctx context.Context, cfg aws.Config) (*ec2.DescribeInstancesOu
 	}
 
 	instanceIDs := SSMGetInstanceID(ssmInstances)
+	if len(instanceIDs) == 0 {
+		// without instance ids DescribeInstances would return every instance
+		return &ec2.DescribeInstancesOutput{}, nil
+	}
 
 	spanDescribeInstances, ctx := opentracing.StartSpanFromContext(ctx, "ec2getinstances")
 	defer spanDescribeInstances.Finish()
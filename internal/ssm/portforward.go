@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/ssm_plugin"
 	"github.com/AndreZiviani/aws-fuzzy/internal/sso"
@@ -15,6 +18,9 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
+// how long StartPortForward waits for the local port to be opened
+const portForwardTimeout = 30 * time.Second
+
 func NewPortForward(profile, region, ports string) *PortForward {
 	pf := PortForward{
 		Profile: profile,
@@ -25,19 +31,17 @@ func NewPortForward(profile, region, ports string) *PortForward {
 	return &pf
 }
 
-func (p *PortForward) DoPortForward(ctx context.Context, id, local, host, remote string) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ssmportforward")
-	defer span.Finish()
-
+// startSession starts an SSM port forwarding session and returns the arguments for the plugin
+func (p *PortForward) startSession(ctx context.Context, id, local, host, remote string) (*awsssm.Client, *awsssm.StartSessionOutput, []string, error) {
 	login := sso.Login{Profile: p.Profile}
 	creds, err := login.GetCredentials(ctx)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	cfg, err := sso.NewAwsConfig(ctx, creds, config.WithRegion(p.Region))
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	docName := docPortForwardRemoteHost
@@ -58,7 +62,7 @@ func (p *PortForward) DoPortForward(ctx context.Context, id, local, host, remote
 	sessionJson, _ := json.Marshal(session)
 
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	/*
@@ -80,14 +84,28 @@ func (p *PortForward) DoPortForward(ctx context.Context, id, local, host, remote
 		session.ValidadeInputAndStartSession(input, os.Stdout)
 	*/
 
-	// for now we have to use the embeded the binary
-	_ = ssm_plugin.RunPlugin(
+	args := []string{
 		string(sessionJson),
 		p.Region,
 		"StartSession",
 		p.Profile,
 		string(inputJson),
-	)
+	}
+
+	return ssmclient, session, args, nil
+}
+
+func (p *PortForward) DoPortForward(ctx context.Context, id, local, host, remote string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ssmportforward")
+	defer span.Finish()
+
+	ssmclient, session, args, err := p.startSession(ctx, id, local, host, remote)
+	if err != nil {
+		return err
+	}
+
+	// for now we have to use the embeded the binary
+	_ = ssm_plugin.RunPlugin(args...)
 
 	_, err = ssmclient.TerminateSession(ctx, &awsssm.TerminateSessionInput{
 		SessionId: session.SessionId,
@@ -96,6 +114,84 @@ func (p *PortForward) DoPortForward(ctx context.Context, id, local, host, remote
 	return err
 }
 
+// PortForwardSession is a port forwarding session running in the background
+type PortForwardSession struct {
+	client  *awsssm.Client
+	session *awsssm.StartSessionOutput
+	plugin  *exec.Cmd
+	exited  chan struct{}
+}
+
+// StartPortForward forwards the local port to the remote host in the background and waits until the local port
+// accepts connections, the session must be stopped with Close
+func (p *PortForward) StartPortForward(ctx context.Context, id, local, host, remote string) (*PortForwardSession, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ssmportforward")
+	defer span.Finish()
+
+	ssmclient, session, args, err := p.startSession(ctx, id, local, host, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &PortForwardSession{client: ssmclient, session: session, exited: make(chan struct{})}
+
+	pf.plugin, err = ssm_plugin.StartPlugin(args...)
+	if err != nil {
+		_ = pf.Close(ctx)
+		return nil, err
+	}
+
+	go func() {
+		_ = pf.plugin.Wait()
+		close(pf.exited)
+	}()
+
+	err = pf.waitForPort(ctx, local)
+	if err != nil {
+		_ = pf.Close(ctx)
+		return nil, err
+	}
+
+	return pf, nil
+}
+
+func (pf *PortForwardSession) waitForPort(ctx context.Context, local string) error {
+	address := net.JoinHostPort("127.0.0.1", local)
+	timeout := time.After(portForwardTimeout)
+
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+
+		select {
+		case <-pf.exited:
+			return fmt.Errorf("the session manager plugin exited before port %s was opened", local)
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for port %s to be opened", local)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// Close stops the plugin and terminates the session
+func (pf *PortForwardSession) Close(ctx context.Context) error {
+	if pf.plugin != nil && pf.plugin.Process != nil {
+		_ = pf.plugin.Process.Kill()
+		<-pf.exited
+	}
+
+	_, err := pf.client.TerminateSession(ctx, &awsssm.TerminateSessionInput{
+		SessionId: pf.session.SessionId,
+	})
+
+	return err
+}
+
 func (p *PortForward) Execute(ctx context.Context) error {
 	closer, err := tracing.InitTracing()
 	if err != nil {
@@ -125,11 +221,15 @@ func (p *PortForward) Execute(ctx context.Context) error {
 
 	span.Finish()
 
-	instance, err := tui(instances)
+	instance, console, err := tui(instances)
 	if err != nil {
 		return err
 	}
 
+	if console {
+		return sso.OpenResource(ctx, p.Profile, cfg.Region, sso.EC2InstanceArn(cfg.Region, instance.OwnerId, aws.ToString(instance.InstanceId)))
+	}
+
 	ports := strings.Split(p.Ports, ":")
 
 	return p.DoPortForward(ctx, aws.ToString(instance.InstanceId), ports[0], ports[1], ports[2])
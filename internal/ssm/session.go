@@ -121,10 +121,14 @@ func (p *Session) Execute(ctx context.Context) error {
 
 	span.Finish()
 
-	instance, err := tui(instances)
+	instance, console, err := tui(instances)
 	if err != nil {
 		return err
 	}
 
+	if console {
+		return sso.OpenResource(ctx, p.Profile, cfg.Region, sso.EC2InstanceArn(cfg.Region, instance.OwnerId, aws.ToString(instance.InstanceId)))
+	}
+
 	return p.DoSsm(ctx, aws.ToString(instance.InstanceId))
 }
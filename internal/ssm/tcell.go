@@ -130,6 +130,16 @@ func (t *Tui) setCaptureEvents() {
 			t.selected = &t.instances[instanceIdx]
 			t.app.Stop()
 			return nil
+		case tcell.KeyCtrlO:
+			// open the selected instance in the console
+			if t.resourceList.GetItemCount() == 0 {
+				return nil
+			}
+			current := t.resourceList.GetCurrentItem()
+			t.selected = &t.instances[t.instanceIdx[current]]
+			t.console = true
+			t.app.Stop()
+			return nil
 		case tcell.KeyTab:
 			switch where {
 			case t.resourceDetails:
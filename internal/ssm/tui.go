@@ -14,6 +14,8 @@ import (
 
 type Instance struct {
 	ec2types.Instance
+	// account that owns the reservation of the instance
+	OwnerId string
 }
 
 func (i Instance) PrintName() string {
@@ -133,6 +135,8 @@ type Tui struct {
 	instances       []Instance
 	selected        *Instance
 	instanceIdx     []int
+	// the selected instance should be opened in the console
+	console bool
 }
 
 type FzfData struct {
@@ -148,6 +152,7 @@ func NewFzfData(instancesOutput *ec2.DescribeInstancesOutput) *FzfData {
 		for _, i := range r.Instances {
 			tmp := Instance{}
 			tmp.Instance = i // force ec2type.Instance to be my Instance type, see embedded struct doc
+			tmp.OwnerId = aws.ToString(r.OwnerId)
 			f.Instances = append(f.Instances, tmp)
 		}
 	}
@@ -216,7 +221,8 @@ func (t *Tui) inputFunc(text string) {
 	boldItem(t.resourceList, t.resourceList.GetCurrentItem())
 }
 
-func tui(instancesOutput *ec2.DescribeInstancesOutput) (*Instance, error) {
+// tui returns the selected instance and whether it should be opened in the console (ctrl+o) instead
+func tui(instancesOutput *ec2.DescribeInstancesOutput) (*Instance, bool, error) {
 
 	t := NewTui()
 
@@ -243,8 +249,8 @@ func tui(instancesOutput *ec2.DescribeInstancesOutput) (*Instance, error) {
 
 	if t.selected == nil {
 		// user aborted the selection (ctrl+c?)
-		return nil, fmt.Errorf("aborting by user request")
+		return nil, false, fmt.Errorf("aborting by user request")
 	}
 
-	return t.selected, nil
+	return t.selected, t.console, nil
 }
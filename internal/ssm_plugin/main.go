@@ -105,6 +105,23 @@ func RunPlugin(args ...string) error {
 	return nil
 }
 
+// StartPlugin starts the plugin in the background, it does not use the terminal so it can run
+// alongside an interactive command, stop it by killing the returned process
+func StartPlugin(args ...string) (*exec.Cmd, error) {
+	process, err := ExtractAssets()
+	if err != nil {
+		return nil, err
+	}
+
+	call := exec.Command(process, args...)
+	call.SysProcAttr = detachedProcAttr()
+
+	if err := call.Start(); err != nil {
+		return nil, WrapError(err)
+	}
+	return call, nil
+}
+
 func WrapError(err error) error {
 	if err != nil {
 		// Get program counter and line number
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package ssm_plugin
+
+import (
+	"syscall"
+)
+
+// detachedProcAttr starts the plugin in its own process group so ctrl+c in the terminal does not stop it
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
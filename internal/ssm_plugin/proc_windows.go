@@ -0,0 +1,10 @@
+package ssm_plugin
+
+import (
+	"syscall"
+)
+
+// detachedProcAttr starts the plugin in its own process group so ctrl+c in the console does not stop it
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,236 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/common-fate/clio"
+)
+
+const (
+	// used when the profile does not set duration_seconds
+	defaultRoleDuration = time.Hour
+	// lifetime of the session token requested with MFA, same as the AWS CLI
+	mfaSessionDuration = 12 * time.Hour
+)
+
+// roleDuration returns the duration_seconds of the profile or the default role duration
+func roleDuration(profile *awsprofile.Profile) time.Duration {
+	if profile.AWSConfig.RoleDurationSeconds != nil && *profile.AWSConfig.RoleDurationSeconds > 0 {
+		return *profile.AWSConfig.RoleDurationSeconds
+	}
+
+	return defaultRoleDuration
+}
+
+// mfaSerial returns the first mfa_serial of the chain, the MFA device always belongs to the IAM user of the root profile
+func mfaSerial(chain []*awsprofile.Profile) string {
+	for _, profile := range chain {
+		if profile.AWSConfig.MFASerial != "" {
+			return profile.AWSConfig.MFASerial
+		}
+	}
+
+	return ""
+}
+
+// chainCredentials returns the credentials of the profile by walking its source_profile chain,
+// starting from the deepest level with valid cached credentials and caching the credentials of every level
+func (p *Login) chainCredentials(ctx context.Context, profile *awsprofile.Profile) (aws.Credentials, error) {
+	chain := append(append([]*awsprofile.Profile{}, profile.Parents...), profile)
+	credstore := securestorage.NewSecureRoleCredentialsStorage()
+
+	start := -1
+	var creds aws.Credentials
+
+	if !p.NoCache {
+		// the profile itself was already checked by GetCredentials
+		for i := len(chain) - 2; i >= 0; i-- {
+			if cached := credstore.GetValidCredentials(ctx, chain[i].Name); cached != nil {
+				clio.Debugf("using cached credentials of profile %s", chain[i].Name)
+				start, creds = i, *cached
+				break
+			}
+		}
+	}
+
+	// an IAM user with MFA gets a session token once and uses it for every role of the chain
+	useMFASession := chain[0].ProfileType == awsprofile.ProfileTypeIAM && mfaSerial(chain) != ""
+
+	if start < 0 {
+		p.AskAuth()
+		p.checkExpiredCreds(ctx)
+
+		clio.Infof("Could not find cached credentials, refreshing...")
+
+		var err error
+		creds, useMFASession, err = p.rootCredentials(ctx, chain[0], mfaSerial(chain), useMFASession)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+
+		start = 0
+		if creds.CanExpire {
+			// plain access keys of an IAM user are not cached, they are read from the profile every time
+			credstore.StoreCredentials(ctx, chain[0].Name, creds)
+		}
+	} else if useMFASession {
+		// the cached credentials only come from an MFA session if one is still cached, the root profile may have no access keys
+		mfaStore := securestorage.NewSecureMFASessionStorage()
+		useMFASession = mfaStore.GetValidSession(ctx, mfaSerial(chain)) != nil
+	}
+
+	for _, hop := range chain[start+1:] {
+		var err error
+		creds, err = p.assumeRole(ctx, hop, creds, !useMFASession)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("failed to assume role of profile %s, %w", hop.Name, err)
+		}
+
+		credstore.StoreCredentials(ctx, hop.Name, creds)
+	}
+
+	return creds, nil
+}
+
+// rootCredentials returns the credentials of the first profile of the chain and whether they are an MFA session,
+// the roles of the chain must then be assumed without MFA
+func (p *Login) rootCredentials(ctx context.Context, root *awsprofile.Profile, serial string, useMFASession bool) (aws.Credentials, bool, error) {
+	if useMFASession {
+		creds, err := p.mfaSession(ctx, root, serial)
+		if err == nil {
+			return creds, true, nil
+		}
+		if !errors.Is(err, errNoStaticCredentials) {
+			return aws.Credentials{}, false, err
+		}
+		clio.Debugf("profile %s has no access keys, not using an MFA session", root.Name)
+	}
+
+	creds, err := root.AssumeTerminal(ctx, awsprofile.ConfigOpts{Duration: roleDuration(root), PrintOnly: p.Url})
+
+	return creds, false, err
+}
+
+var errNoStaticCredentials = errors.New("profile has no access keys")
+
+// mfaSession returns session credentials of the IAM user of the profile obtained with MFA,
+// they are cached by MFA device so the token is asked once for every profile using it
+func (p *Login) mfaSession(ctx context.Context, root *awsprofile.Profile, serial string) (aws.Credentials, error) {
+	store := securestorage.NewSecureMFASessionStorage()
+
+	cached := store.GetValidSession(ctx, serial)
+	if cached != nil {
+		clio.Debugf("using cached MFA session of %s", serial)
+		return *cached, nil
+	}
+
+	static := root.AWSConfig.Credentials
+	if root.HasSecureStorageIAMCredentials {
+		iamStore := securestorage.NewSecureIAMCredentialStorage()
+		var err error
+		static, err = iamStore.GetCredentials(ctx, root.Name)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+	}
+
+	if !static.HasKeys() {
+		return aws.Credentials{}, errNoStaticCredentials
+	}
+
+	token, err := p.mfaToken(ctx, root, serial)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	cfg, err := NewAwsConfig(ctx, &static)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	out, err := sts.NewFromConfig(cfg).GetSessionToken(ctx, &sts.GetSessionTokenInput{
+		SerialNumber:    &serial,
+		TokenCode:       &token,
+		DurationSeconds: aws.Int32(int32(mfaSessionDuration.Seconds())),
+	})
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	creds := awsprofile.TypeCredsToAwsCreds(*out.Credentials)
+	store.StoreSession(ctx, serial, creds)
+
+	return creds, nil
+}
+
+// mfaToken returns the token given with --token, it can only be used once,
+// or one from the MFA command or TOTP secret of the profile, or asks for one
+func (p *Login) mfaToken(ctx context.Context, profile *awsprofile.Profile, serial string) (string, error) {
+	if p.MFATOTP != "" {
+		token := p.MFATOTP
+		p.MFATOTP = ""
+		return token, nil
+	}
+
+	return awsprofile.MfaTokenProviderFor(ctx, profile, serial)()
+}
+
+// assumeRole assumes the role of the profile with the credentials of its source profile
+func (p *Login) assumeRole(ctx context.Context, profile *awsprofile.Profile, creds aws.Credentials, withMFA bool) (aws.Credentials, error) {
+	opts := []func(*config.LoadOptions) error{}
+
+	region, err := profile.Region(ctx)
+	if err == nil {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := NewAwsConfig(ctx, &creds, opts...)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	if cfg.Region == "" {
+		// STS is available in every region, only the partition matters
+		cfg.Region = "us-east-1"
+	}
+
+	sessionName := profile.AWSConfig.RoleSessionName
+	if sessionName == "" {
+		sessionName = profile.Name
+	}
+
+	input := sts.AssumeRoleInput{
+		RoleArn:         &profile.AWSConfig.RoleARN,
+		RoleSessionName: &sessionName,
+		DurationSeconds: aws.Int32(int32(roleDuration(profile).Seconds())),
+	}
+
+	if profile.AWSConfig.ExternalID != "" {
+		input.ExternalId = &profile.AWSConfig.ExternalID
+	}
+
+	if withMFA && profile.AWSConfig.MFASerial != "" {
+		token, err := p.mfaToken(ctx, profile, profile.AWSConfig.MFASerial)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+
+		input.SerialNumber = &profile.AWSConfig.MFASerial
+		input.TokenCode = &token
+	}
+
+	out, err := sts.NewFromConfig(cfg).AssumeRole(ctx, &input)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+
+	return awsprofile.TypeCredsToAwsCreds(*out.Credentials), nil
+}
@@ -3,6 +3,7 @@ package sso
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -51,17 +52,26 @@ func (p *Login) GetProfileFromID(id string) (*awsprofile.Profile, error) {
 	return nil, fmt.Errorf("could not find a profile with account id %s", id)
 }
 
-func (p *Login) PrintCredentials(creds *aws.Credentials) {
-	fmt.Printf(
-		"export AWS_ACCESS_KEY_ID='%s'\n"+
-			"export AWS_SECRET_ACCESS_KEY='%s'\n"+
-			"export AWS_SESSION_TOKEN='%s'\n"+
-			"export AWS_SECURITY_TOKEN='%s'\n"+
-			"export AWS_EXPIRES='%s'\n",
-		creds.AccessKeyID,
-		creds.SecretAccessKey,
-		creds.SessionToken,
-		creds.SessionToken,
-		creds.Expires.String(),
-	)
+func (p *Login) PrintCredentials(ctx context.Context, creds *aws.Credentials) error {
+	format := p.Format
+	if format == "" {
+		format = DetectFormat(os.Getenv("SHELL"))
+	}
+
+	// profile and region are optional, credentials are still usable without them
+	name, region := "", ""
+	profile, err := p.GetProfile(p.Profile)
+	if err == nil {
+		name = profile.Name
+		region, _ = profile.Region(ctx)
+	}
+
+	output, err := FormatCredentials(format, creds, name, region)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+
+	return nil
 }
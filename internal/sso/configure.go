@@ -2,140 +2,483 @@ package sso
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
 	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
 	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/common-fate/clio"
 	opentracing "github.com/opentracing/opentracing-go"
 	"gopkg.in/ini.v1"
 )
 
+const (
+	DefaultNameTemplate         = "{{.AccountName}}"
+	DefaultAllRolesNameTemplate = "{{.AccountName}}-{{.Role}}"
+)
+
+type SsoSession struct {
+	StartUrl string `ini:"aws_fuzzy_sso_start_url"`
+	Region   string `ini:"aws_fuzzy_sso_region"`
+}
+
 type AwsProfile struct {
-	StartUrl          string `ini:"granted_sso_start_url"`
-	Region            string `ini:"granted_sso_region"`
-	AccountId         string `ini:"granted_sso_account_id"`
-	Role              string `ini:"granted_sso_role_name"`
+	Session           string `ini:"aws_fuzzy_sso_session"`
+	AccountId         string `ini:"aws_fuzzy_sso_account_id"`
+	Role              string `ini:"aws_fuzzy_sso_role_name"`
 	CredentialProcess string `ini:"credential_process"`
 }
 
-func NewConfigure(verbose bool) *Configure {
+// keys written by previous versions, they are removed when a profile is written again
+var legacyProfileKeys = []string{
+	"granted_sso_start_url",
+	"granted_sso_region",
+	"granted_sso_account_id",
+	"granted_sso_role_name",
+	"aws_fuzzy_sso_start_url",
+	"aws_fuzzy_sso_region",
+}
+
+// AccountAccess is an account and the roles the user can access on it
+type AccountAccess struct {
+	AccountID   string
+	AccountName string
+	Email       string
+	Roles       []string
+}
+
+// ProfileNameData is available to the profile name template
+type ProfileNameData struct {
+	AccountName string
+	AccountID   string
+	Email       string
+	Role        string
+}
+
+func NewConfigure(startURL, region, sessionName string, rolePreference []string, nameTemplate string, allRoles, yes, prune, verbose bool) *Configure {
 	configure := Configure{
-		Verbose: verbose,
+		StartURL:       startURL,
+		Region:         region,
+		SessionName:    sessionName,
+		RolePreference: rolePreference,
+		NameTemplate:   nameTemplate,
+		AllRoles:       allRoles,
+		Yes:            yes,
+		Prune:          prune,
+		Verbose:        verbose,
 	}
 
 	return &configure
 }
 
-func (p *Configure) GetAccountAccess(ctx context.Context, startURL string, region string) (map[string]AwsProfile, error) {
-	cfg, _ := NewAwsConfig(ctx, nil)
+// ListAccountAccess lists every account and role the token has access to
+func (p *Configure) ListAccountAccess(ctx context.Context, ssoclient *sso.Client, accessToken string) ([]AccountAccess, error) {
+	accounts := make([]AccountAccess, 0)
+
+	paginator := sso.NewListAccountsPaginator(ssoclient, &sso.ListAccountsInput{AccessToken: &accessToken})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts, %w", err)
+		}
+
+		for _, account := range out.AccountList {
+			accounts = append(accounts, AccountAccess{
+				AccountID:   aws.ToString(account.AccountId),
+				AccountName: aws.ToString(account.AccountName),
+				Email:       aws.ToString(account.EmailAddress),
+			})
+		}
+	}
+
+	for i := range accounts {
+		rolesPaginator := sso.NewListAccountRolesPaginator(ssoclient, &sso.ListAccountRolesInput{
+			AccessToken: &accessToken,
+			AccountId:   &accounts[i].AccountID,
+		})
+		for rolesPaginator.HasMorePages() {
+			out, err := rolesPaginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list account roles, %w", err)
+			}
+
+			for _, role := range out.RoleList {
+				accounts[i].Roles = append(accounts[i].Roles, aws.ToString(role.RoleName))
+			}
+		}
+
+		sort.Slice(accounts[i].Roles, func(a, b int) bool {
+			return strings.ToLower(accounts[i].Roles[a]) < strings.ToLower(accounts[i].Roles[b])
+		})
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return strings.ToLower(accounts[i].AccountName) < strings.ToLower(accounts[j].AccountName)
+	})
+
+	return accounts, nil
+}
+
+// PreferredRole returns the first role of the preference list the account has, or the first role alphabetically
+func (p *Configure) PreferredRole(account AccountAccess) string {
+	for _, preferred := range p.RolePreference {
+		for _, role := range account.Roles {
+			if strings.EqualFold(role, strings.TrimSpace(preferred)) {
+				return role
+			}
+		}
+	}
+
+	return account.Roles[0]
+}
+
+// ProfileName renders the name template for an account and role
+func (p *Configure) ProfileName(account AccountAccess, role string) (string, error) {
+	nameTemplate := p.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = DefaultNameTemplate
+		if p.AllRoles {
+			nameTemplate = DefaultAllRolesNameTemplate
+		}
+	}
+
+	tmpl, err := template.New("name").Parse(nameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid name template, %w", err)
+	}
+
+	out := bytes.NewBufferString("")
+	err = tmpl.Execute(out, ProfileNameData{
+		AccountName: account.AccountName,
+		AccountID:   account.AccountID,
+		Email:       account.Email,
+		Role:        role,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid name template, %w", err)
+	}
+
+	return strings.ReplaceAll(strings.TrimSpace(out.String()), " ", "_"), nil
+}
+
+// stdin returns a reader shared by all prompts so buffered input is not lost between them
+func (p *Configure) stdin() *bufio.Reader {
+	if p.reader == nil {
+		p.reader = bufio.NewReader(os.Stdin)
+	}
+	return p.reader
+}
+
+func prompt(reader *bufio.Reader, format string, args ...any) string {
+	fmt.Printf(format, args...)
+	text, _ := reader.ReadString('\n')
+	return strings.TrimSpace(text)
+}
+
+// selectRoles returns the roles that will have a profile for the account
+func (p *Configure) selectRoles(reader *bufio.Reader, account AccountAccess) []string {
+	if p.AllRoles {
+		return account.Roles
+	}
+
+	return []string{p.selectRole(reader, account)}
+}
+
+func (p *Configure) selectRole(reader *bufio.Reader, account AccountAccess) string {
+	role := p.PreferredRole(account)
+
+	if p.Yes || len(account.Roles) == 1 {
+		return role
+	}
+
+	fmt.Printf("Found %d roles for account %s:\n", len(account.Roles), account.AccountName)
+	for _, v := range account.Roles {
+		fmt.Println(v)
+	}
+
+	for {
+		text := prompt(reader, "Which one do you want? (default: %s) ", role)
+		if text == "" {
+			return role
+		}
+
+		for _, v := range account.Roles {
+			if strings.EqualFold(v, text) {
+				return v
+			}
+		}
+
+		fmt.Printf("Role %s not found for account %s\n", text, account.AccountName)
+	}
+}
+
+// DefaultSessionName derives the sso-session name from the start url, e.g. 'example' for https://example.awsapps.com/start
+func DefaultSessionName(startURL string) string {
+	u, err := url.Parse(startURL)
+	if err != nil || u.Hostname() == "" {
+		return "aws-fuzzy"
+	}
+
+	return strings.Split(u.Hostname(), ".")[0]
+}
+
+// sessionName returns the name of the sso-session section for startURL, an existing section for the same start url is reused
+func (p *Configure) sessionName(c *ini.File, startURL string) (string, error) {
+	if p.SessionName != "" {
+		s, err := c.GetSection("sso-session " + p.SessionName)
+		if err == nil && s.HasKey("aws_fuzzy_sso_start_url") && s.Key("aws_fuzzy_sso_start_url").Value() != startURL {
+			return "", fmt.Errorf("sso-session %s already exists with a different start url", p.SessionName)
+		}
+
+		return p.SessionName, nil
+	}
+
+	for _, s := range c.Sections() {
+		if strings.HasPrefix(s.Name(), "sso-session ") && keyValue(s, "aws_fuzzy_sso_start_url") == startURL {
+			return strings.TrimPrefix(s.Name(), "sso-session "), nil
+		}
+	}
+
+	name := DefaultSessionName(startURL)
+	if c.HasSection("sso-session " + name) {
+		return "", fmt.Errorf("sso-session %s already exists with a different start url, use --session-name to choose another name", name)
+	}
+
+	return name, nil
+}
+
+// keyValue returns the value of key or an empty string, unlike Section.Key it does not create missing keys
+func keyValue(s *ini.Section, key string) string {
+	if !s.HasKey(key) {
+		return ""
+	}
+
+	return s.Key(key).Value()
+}
+
+// managedProfiles returns the profiles of the config file that were generated for the session or,
+// by previous versions, for its start url, by section name
+func managedProfiles(c *ini.File, session string, startURL string) map[string]AwsProfile {
+	managed := make(map[string]AwsProfile)
+
+	for _, s := range c.Sections() {
+		if !strings.HasPrefix(s.Name(), "profile ") {
+			continue
+		}
+
+		var existing AwsProfile
+		switch {
+		case keyValue(s, "aws_fuzzy_sso_session") == session:
+		case keyValue(s, "aws_fuzzy_sso_start_url") == startURL:
+		case keyValue(s, "granted_sso_start_url") == startURL:
+			existing.AccountId = keyValue(s, "granted_sso_account_id")
+			existing.Role = keyValue(s, "granted_sso_role_name")
+		default:
+			continue
+		}
+
+		if existing.AccountId == "" {
+			existing.AccountId = keyValue(s, "aws_fuzzy_sso_account_id")
+			existing.Role = keyValue(s, "aws_fuzzy_sso_role_name")
+		}
+
+		if existing.AccountId == "" {
+			continue
+		}
+
+		managed[s.Name()] = existing
+	}
+
+	return managed
+}
+
+// profileSection returns the section of a new profile, suffixed with the account ID when the name is already used by
+// another profile of this run or by a section of the config, profiles of the same account and role keep their
+// section before a new one is chosen so an existing section always belongs to something else
+func profileSection(c *ini.File, name, accountID string, profiles map[string]AwsProfile) (string, error) {
+	taken := func(section string) bool {
+		if _, ok := profiles[section]; ok {
+			return true
+		}
+		return c.HasSection(section)
+	}
+
+	section := fmt.Sprintf("profile %s", name)
+	if !taken(section) {
+		return section, nil
+	}
+
+	suffixed := fmt.Sprintf("profile %s-%s", name, accountID)
+	if !taken(suffixed) {
+		return suffixed, nil
+	}
+
+	return "", fmt.Errorf("profiles %s and %s-%s already exist in the config, choose another name", name, name, accountID)
+}
+
+func (p *Configure) GetAccountAccess(ctx context.Context, c *ini.File, session string, startURL string, region string, existing map[string]AwsProfile) (map[string]AwsProfile, []AccountAccess, error) {
+	cfg, _ := NewAwsConfig(ctx, nil, config.WithRegion(region))
 	ssoclient := sso.NewFromConfig(cfg)
 
+	// profiles using a sso-session share the token stored with the session name
 	secureSSOTokenStorage := securestorage.NewSecureSSOTokenStorage()
-	ssoToken := secureSSOTokenStorage.GetValidSSOToken(ctx, startURL)
+	ssoToken := secureSSOTokenStorage.GetValidSSOToken(ctx, session)
 	if ssoToken == nil {
 		var err error
-		ssoToken, err = awsprofile.SSOLoginFlow(ctx, cfg, startURL, startURL, false)
+		ssoToken, err = awsprofile.SSOLoginFlow(ctx, cfg, startURL, session, false)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		secureSSOTokenStorage.StoreSSOToken(ctx, session, *ssoToken)
 	}
 
-	// List available account
-	accounts, err := ssoclient.ListAccounts(ctx,
-		&sso.ListAccountsInput{
-			AccessToken: &ssoToken.AccessToken,
-			MaxResults:  aws.Int32(100),
-		},
-	)
+	accounts, err := p.ListAccountAccess(ctx, ssoclient, ssoToken.AccessToken)
 	if err != nil {
-		fmt.Printf("failed to list accounts, %s\n", err)
-		return map[string]AwsProfile{}, err
+		return nil, nil, err
 	}
 
-	profiles := NewSsoProfiles()
-	for _, account := range accounts.AccountList {
-		// List Available roles in each account
-		roles, err := ssoclient.ListAccountRoles(ctx,
-			&sso.ListAccountRolesInput{
-				AccessToken: &ssoToken.AccessToken,
-				AccountId:   account.AccountId,
-				MaxResults:  aws.Int32(100),
-			},
-		)
-		if err != nil {
-			fmt.Printf("failed to list account roles, %s\n", err)
-			return map[string]AwsProfile{}, err
-		}
-
-		role := aws.ToString(roles.RoleList[0].RoleName)
-		reader := bufio.NewReader(os.Stdin)
+	// keep the name of profiles that were already configured for the same account and role
+	existingNames := make(map[string]string)
+	for name, v := range existing {
+		existingNames[v.AccountId+"/"+v.Role] = name
+	}
 
-		if len(roles.RoleList) > 1 {
-			fmt.Printf("Found %d roles for account %s:\n", len(roles.RoleList), *account.AccountName)
-			sort.Slice(roles.RoleList, func(i, j int) bool {
-				return strings.ToLower(aws.ToString(roles.RoleList[i].RoleName)) < strings.ToLower(aws.ToString(roles.RoleList[j].RoleName))
-			})
-			for _, v := range roles.RoleList {
-				fmt.Println(aws.ToString(v.RoleName))
-			}
+	reader := p.stdin()
+	profiles := NewSsoProfiles()
+	afcfg := afconfig.NewDefaultConfig()
 
-			fmt.Printf("Which one do you want? (default: %s) ", aws.ToString(roles.RoleList[0].RoleName))
-			text, _ := reader.ReadString('\n')
+	for _, account := range accounts {
+		if len(account.Roles) == 0 {
+			continue
+		}
 
-			if text != "\n" {
-				role = text
+		for _, role := range p.selectRoles(reader, account) {
+			section, ok := existingNames[account.AccountID+"/"+role]
+			if !ok {
+				name, err := p.ProfileName(account, role)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				if !p.Yes {
+					text := prompt(reader, "Profile name for role %s of account %s: (default: %s) ", role, account.AccountName, name)
+					if len(text) != 0 {
+						name = text
+					}
+				}
+
+				section, err = profileSection(c, name, account.AccountID, profiles)
+				if err != nil {
+					return nil, nil, err
+				}
 			}
 
-			// TODO: check for typo in role name?
+			name := strings.TrimPrefix(section, "profile ")
+			process := fmt.Sprintf("%s sso credential-process --profile %s", afcfg.AppName, name)
+			profiles[section] = AwsProfile{session, account.AccountID, role, process}
 		}
+	}
 
-		fmt.Printf("Profile name for account %s: (defaults to account name) ", *account.AccountName)
-		text, _ := reader.ReadString('\n')
-		text = strings.TrimSuffix(text, "\n")
+	return profiles, accounts, err
+}
 
-		tmp := strings.ReplaceAll(*account.AccountName, " ", "_")
-		if len(text) != 0 {
-			tmp = text
+// pruneCandidates returns the managed profiles whose account or role is no longer accessible
+func pruneCandidates(existing map[string]AwsProfile, accounts []AccountAccess) []string {
+	access := make(map[string]struct{})
+	for _, account := range accounts {
+		for _, role := range account.Roles {
+			access[account.AccountID+"/"+role] = struct{}{}
 		}
+	}
 
-		profile := fmt.Sprintf("profile %s", tmp)
-		process := fmt.Sprintf("%s sso credential-process --profile %s", os.Args[0], tmp)
-		profiles[profile] = AwsProfile{startURL, region, *account.AccountId, role, process}
+	prune := make([]string, 0)
+	for name, v := range existing {
+		if _, ok := access[v.AccountId+"/"+v.Role]; !ok {
+			prune = append(prune, name)
+		}
 	}
+	sort.Strings(prune)
 
-	return profiles, err
+	return prune
 }
 
 func (p *Configure) ConfigureProfiles(ctx context.Context) error {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "ssoprofiles")
 	defer span.Finish()
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := p.stdin()
+
+	if p.StartURL == "" {
+		if p.Yes {
+			return fmt.Errorf("--start-url is required with --yes")
+		}
+		p.StartURL = prompt(reader, "SSO start url: ")
+	}
+
+	if p.Region == "" {
+		if p.Yes {
+			return fmt.Errorf("--region is required with --yes")
+		}
+		p.Region = prompt(reader, "SSO region: ")
+	}
+
+	configPath := config.DefaultSharedConfigFilename()
 
-	fmt.Print("SSO start url: ")
-	startURL, _ := reader.ReadString('\n')
-	startURL = strings.ReplaceAll(startURL, "\n", "")
+	c, err := ini.LoadSources(ini.LoadOptions{Loose: true}, configPath)
+	if err != nil {
+		return err
+	}
 
-	fmt.Print("SSO region: ")
-	region, _ := reader.ReadString('\n')
-	region = strings.ReplaceAll(region, "\n", "")
+	session, err := p.sessionName(c, p.StartURL)
+	if err != nil {
+		return err
+	}
 
-	profiles, _ := p.GetAccountAccess(ctx, startURL, region)
+	existing := managedProfiles(c, session, p.StartURL)
 
-	// Save complete profile config
-	err := WriteSsoProfiles(profiles)
+	profiles, accounts, err := p.GetAccountAccess(ctx, c, session, p.StartURL, p.Region, existing)
 	if err != nil {
 		return err
 	}
 
+	prune := make([]string, 0)
+	if p.Prune {
+		prune = pruneCandidates(existing, accounts)
+
+		if len(prune) > 0 && !p.Yes {
+			fmt.Println("The following profiles are no longer accessible and will be removed:")
+			for _, v := range prune {
+				fmt.Println(strings.TrimPrefix(v, "profile "))
+			}
+
+			text := prompt(reader, "Continue? (y/N) ")
+			if !strings.EqualFold(text, "y") {
+				prune = prune[:0]
+			}
+		}
+	}
+
+	// Merge profiles into the existing config
+	err = WriteSsoProfiles(session, SsoSession{p.StartURL, p.Region}, profiles, prune)
+	if err != nil {
+		return err
+	}
+
+	clio.Successf("Configured sso-session %s with %d profiles, removed %d profiles in %s", session, len(profiles), len(prune), configPath)
+
 	return nil
 }
 
@@ -163,10 +506,10 @@ func CopyFile(src, dst string) error {
 	return err
 }
 
-func WriteSsoProfiles(profiles map[string]AwsProfile) error {
-	homeDir, _ := os.UserHomeDir()
-	configDir := fmt.Sprintf("%s/.aws", homeDir)
-	configPath := fmt.Sprintf("%s/config", configDir)
+// WriteSsoProfiles merges the sso-session and its profiles into the AWS config file and removes the prune sections,
+// unrelated sections, keys and comments are preserved
+func WriteSsoProfiles(name string, session SsoSession, profiles map[string]AwsProfile, prune []string) error {
+	configPath := config.DefaultSharedConfigFilename()
 
 	if _, err := os.Stat(configPath); err == nil {
 		// found existing config file, backup before proceeding
@@ -175,29 +518,52 @@ func WriteSsoProfiles(profiles map[string]AwsProfile) error {
 			fmt.Printf("could not backup config, %v\n", err)
 			return err
 		}
-	} else {
-		err = os.Mkdir(configDir, 0700)
-		if err != nil {
-			return err
-		}
 	}
 
-	c := ini.Empty()
+	err := os.MkdirAll(filepath.Dir(configPath), 0700)
+	if err != nil {
+		return err
+	}
 
-	for k, v := range profiles {
-		s, _ := c.NewSection(k)
-		_ = s.ReflectFrom(&v)
+	c, err := ini.LoadSources(ini.LoadOptions{Loose: true}, configPath)
+	if err != nil {
+		return err
 	}
 
-	f, err := os.Create(configPath)
+	for _, k := range prune {
+		c.DeleteSection(k)
+	}
 
+	err = c.Section("sso-session " + name).ReflectFrom(&session)
 	if err != nil {
-		fmt.Printf("failed to write SSO profiles, %s\n", err)
 		return err
 	}
-	defer func() { _ = f.Close() }()
 
-	_, err = c.WriteTo(f)
+	names := make([]string, 0, len(profiles))
+	for k := range profiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		v := profiles[k]
+		s := c.Section(k)
+		for _, key := range legacyProfileKeys {
+			s.DeleteKey(key)
+		}
+
+		err = s.ReflectFrom(&v)
+		if err != nil {
+			return err
+		}
+	}
+
+	// do not align keys, it would reformat sections that we did not touch
+	prettyFormat, prettyEqual := ini.PrettyFormat, ini.PrettyEqual
+	ini.PrettyFormat, ini.PrettyEqual = false, true
+	defer func() { ini.PrettyFormat, ini.PrettyEqual = prettyFormat, prettyEqual }()
+
+	err = c.SaveTo(configPath)
 	if err != nil {
 		fmt.Printf("failed to write SSO profiles, %s\n", err)
 		return err
@@ -217,5 +583,9 @@ func (p *Configure) Execute(ctx context.Context) error {
 	spanSso, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ssoconfigure")
 	defer spanSso.Finish()
 
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
 	return p.ConfigureProfiles(ctx)
 }
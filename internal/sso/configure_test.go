@@ -0,0 +1,50 @@
+package sso
+
+import (
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestProfileSection(t *testing.T) {
+	c, err := ini.Load([]byte(`
+[profile dev]
+region = us-east-1
+
+[profile prod]
+region = us-east-1
+
+[profile prod-123456789012]
+region = us-east-1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profiles := map[string]AwsProfile{
+		"profile staging": {AccountId: "210987654321", Role: "admin"},
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"sandbox", "profile sandbox", false},
+		// a section that is not managed by the session
+		{"dev", "profile dev-123456789012", false},
+		// another profile of this run
+		{"staging", "profile staging-123456789012", false},
+		{"prod", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := profileSection(c, tt.name, "123456789012", profiles)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -10,14 +10,15 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
-func NewConsole(profile, region, service string, url, verbose, noCache bool) *Console {
+func NewConsole(profile, region, service, resource string, url, verbose, noCache bool) *Console {
 	console := Console{
-		Profile: profile,
-		Region:  region,
-		Service: service,
-		Url:     url,
-		Verbose: verbose,
-		NoCache: noCache,
+		Profile:  profile,
+		Region:   region,
+		Service:  service,
+		Resource: resource,
+		Url:      url,
+		Verbose:  verbose,
+		NoCache:  noCache,
 	}
 
 	return &console
@@ -63,6 +64,13 @@ func (p *Console) OpenBrowser(ctx context.Context) error {
 		Region:  region,
 		Service: p.Service,
 	}
+
+	if p.Resource != "" {
+		con.Region, con.Destination, err = ResourceURL(p.Resource, region)
+		if err != nil {
+			return err
+		}
+	}
 	session, err := con.URL(*credentials)
 	if err != nil {
 		return err
@@ -70,3 +78,10 @@ func (p *Console) OpenBrowser(ctx context.Context) error {
 
 	return awsprofile.LaunchBrowser(session, p.Profile, "console", p.Url)
 }
+
+// OpenResource opens the console page of a resource with the credentials, and browser container, of the profile
+func OpenResource(ctx context.Context, profile, region, resourceArn string) error {
+	console := NewConsole(profile, region, "", resourceArn, false, false, false)
+
+	return console.OpenBrowser(ctx)
+}
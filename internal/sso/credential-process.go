@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
+	"os"
 
 	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -20,6 +20,13 @@ type awsCredsStdOut struct {
 	Expiration      string `json:"Expiration,omitempty"`
 }
 
+// exec --refresh runs commands with a temporary config whose profile gets its credentials from this command, the
+// shared files of the user are passed in these variables so the profile is not resolved from the temporary config
+const (
+	ExecConfigFileEnv      = "AWSFUZZY_EXEC_CONFIG_FILE"
+	ExecCredentialsFileEnv = "AWSFUZZY_EXEC_CREDENTIALS_FILE"
+)
+
 func NewCredentialProcess(profile, token string, verbose bool) *CredentialProcess {
 	cp := CredentialProcess{
 		Profile: profile,
@@ -41,28 +48,41 @@ func (p *CredentialProcess) Execute(ctx context.Context) error {
 	spanSso, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ssocredentialprocesscmd")
 	defer spanSso.Finish()
 
+	restoreExecEnv()
+
 	login := Login{Profile: p.Profile}
-	creds, err := login.GetCredentials(ctx)
+	creds, err := login.GetCredentialsViaAgent(ctx)
 	if err != nil {
 		return err
 	}
 
-	out := awsCredsStdOut{
-		Version:         1,
-		AccessKeyID:     creds.AccessKeyID,
-		SecretAccessKey: creds.SecretAccessKey,
-		SessionToken:    creds.SessionToken,
-	}
-	if creds.CanExpire {
-		out.Expiration = creds.Expires.Format(time.RFC3339)
-	}
-
-	jsonOut, err := json.Marshal(out)
+	jsonOut, err := json.Marshal(newAwsCredsStdOut(creds))
 	if err != nil {
 		return fmt.Errorf("marshalling session credentials")
 	}
 
 	fmt.Println(string(jsonOut))
 	return nil
+}
+
+// restoreExecEnv restores the shared files of the user that exec --refresh replaced with temporary ones
+func restoreExecEnv() {
+	restore := map[string]string{
+		ExecConfigFileEnv:      "AWS_CONFIG_FILE",
+		ExecCredentialsFileEnv: "AWS_SHARED_CREDENTIALS_FILE",
+	}
 
+	for from, to := range restore {
+		value, ok := os.LookupEnv(from)
+		if !ok {
+			continue
+		}
+
+		if value == "" {
+			_ = os.Unsetenv(to)
+		} else {
+			_ = os.Setenv(to, value)
+		}
+		_ = os.Unsetenv(from)
+	}
 }
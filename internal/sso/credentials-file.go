@@ -0,0 +1,52 @@
+package sso
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"gopkg.in/ini.v1"
+)
+
+// WriteCredentialsFile writes creds to a section of a shared credentials file,
+// other sections and comments already present in the file are preserved
+func WriteCredentialsFile(path, section string, creds *aws.Credentials) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	c, err := ini.LoadSources(ini.LoadOptions{Loose: true}, path)
+	if err != nil {
+		return err
+	}
+
+	s := c.Section(section)
+	s.Key("aws_access_key_id").SetValue(creds.AccessKeyID)
+	s.Key("aws_secret_access_key").SetValue(creds.SecretAccessKey)
+	if creds.SessionToken != "" {
+		s.Key("aws_session_token").SetValue(creds.SessionToken)
+	} else {
+		s.DeleteKey("aws_session_token")
+	}
+
+	// write to a temporary file first so readers never see a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = c.WriteTo(tmp)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
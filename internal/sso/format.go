@@ -0,0 +1,199 @@
+package sso
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	FormatSh                = "sh"
+	FormatFish              = "fish"
+	FormatPowershell        = "powershell"
+	FormatCmd               = "cmd"
+	FormatNushell           = "nushell"
+	FormatJson              = "json"
+	FormatDotenv            = "dotenv"
+	FormatCredentialProcess = "credential-process"
+)
+
+var CredentialsFormats = []string{
+	FormatSh,
+	FormatFish,
+	FormatPowershell,
+	FormatCmd,
+	FormatNushell,
+	FormatJson,
+	FormatDotenv,
+	FormatCredentialProcess,
+}
+
+type envVar struct {
+	Name  string
+	Value string
+}
+
+func IsValidFormat(format string) bool {
+	for _, f := range CredentialsFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectFormat returns the output format matching the user shell
+func DetectFormat(shell string) string {
+	return detectFormat(shell, runtime.GOOS)
+}
+
+func detectFormat(shell, goos string) string {
+	// handle windows paths regardless of the current platform
+	name := path.Base(strings.ReplaceAll(shell, `\`, "/"))
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+
+	switch name {
+	case "fish":
+		return FormatFish
+	case "nu", "nushell":
+		return FormatNushell
+	case "pwsh", "powershell":
+		return FormatPowershell
+	case "cmd":
+		return FormatCmd
+	case "", ".":
+		// $SHELL is usually not set on windows
+		if goos == "windows" {
+			return FormatPowershell
+		}
+	}
+
+	return FormatSh
+}
+
+func newAwsCredsStdOut(creds *aws.Credentials) awsCredsStdOut {
+	out := awsCredsStdOut{
+		Version:         1,
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}
+	if creds.CanExpire {
+		out.Expiration = creds.Expires.Format(time.RFC3339)
+	}
+
+	return out
+}
+
+func credentialsEnvVars(creds *aws.Credentials, profile, region string) []envVar {
+	vars := []envVar{
+		{"AWS_ACCESS_KEY_ID", creds.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey},
+		{"AWS_SESSION_TOKEN", creds.SessionToken},
+		{"AWS_SECURITY_TOKEN", creds.SessionToken},
+	}
+
+	if creds.CanExpire {
+		vars = append(vars, envVar{"AWS_EXPIRES", creds.Expires.String()})
+	}
+
+	if region != "" {
+		vars = append(vars,
+			envVar{"AWS_REGION", region},
+			envVar{"AWS_DEFAULT_REGION", region},
+		)
+	}
+
+	if profile != "" {
+		vars = append(vars, envVar{"AWS_PROFILE", profile})
+	}
+
+	// skip empty values, e.g. long lived IAM credentials do not have a session token
+	filtered := make([]envVar, 0, len(vars))
+	for _, v := range vars {
+		if v.Value != "" {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered
+}
+
+// FormatCredentials renders creds in a format that can be consumed by the given shell or tool
+func FormatCredentials(format string, creds *aws.Credentials, profile, region string) (string, error) {
+	if format == FormatCredentialProcess {
+		out, err := json.Marshal(newAwsCredsStdOut(creds))
+		if err != nil {
+			return "", fmt.Errorf("marshalling session credentials")
+		}
+		return string(out) + "\n", nil
+	}
+
+	vars := credentialsEnvVars(creds, profile, region)
+
+	if format == FormatJson {
+		out := make(map[string]string, len(vars))
+		for _, v := range vars {
+			out[v.Name] = v.Value
+		}
+
+		jsonOut, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshalling session credentials")
+		}
+		return string(jsonOut) + "\n", nil
+	}
+
+	var line func(v envVar) string
+
+	switch format {
+	case FormatSh:
+		line = func(v envVar) string {
+			return fmt.Sprintf("export %s='%s'", v.Name, strings.ReplaceAll(v.Value, "'", `'\''`))
+		}
+	case FormatFish:
+		line = func(v envVar) string {
+			value := strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(v.Value)
+			return fmt.Sprintf("set -gx %s '%s';", v.Name, value)
+		}
+	case FormatPowershell:
+		line = func(v envVar) string {
+			return fmt.Sprintf("$Env:%s = '%s'", v.Name, strings.ReplaceAll(v.Value, "'", "''"))
+		}
+	case FormatCmd:
+		// cmd.exe has no escape for a double quote inside a quoted set, values with one are refused
+		for _, v := range vars {
+			if strings.Contains(v.Value, `"`) {
+				return "", fmt.Errorf("%s contains a double quote, it can not be set in cmd.exe, use another format", v.Name)
+			}
+		}
+		line = func(v envVar) string {
+			return fmt.Sprintf(`set "%s=%s"`, v.Name, strings.ReplaceAll(v.Value, "%", "%%"))
+		}
+	case FormatNushell:
+		line = func(v envVar) string {
+			value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v.Value)
+			return fmt.Sprintf(`$env.%s = "%s"`, v.Name, value)
+		}
+	case FormatDotenv:
+		line = func(v envVar) string {
+			// values are not quoted since some consumers (e.g. docker --env-file) do not strip quotes
+			return fmt.Sprintf("%s=%s", v.Name, v.Value)
+		}
+	default:
+		return "", fmt.Errorf("invalid format '%s', expected one of: %s", format, strings.Join(CredentialsFormats, ", "))
+	}
+
+	output := strings.Builder{}
+	for _, v := range vars {
+		output.WriteString(line(v))
+		output.WriteString("\n")
+	}
+
+	return output.String(), nil
+}
@@ -0,0 +1,151 @@
+package sso
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func testCredentials() *aws.Credentials {
+	return &aws.Credentials{
+		AccessKeyID:     "AKIAEXAMPLE",
+		SecretAccessKey: "secret'with\\quote",
+		SessionToken:    "token",
+		CanExpire:       true,
+		Expires:         time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		shell string
+		goos  string
+		want  string
+	}{
+		{"/bin/bash", "linux", FormatSh},
+		{"/usr/bin/zsh", "darwin", FormatSh},
+		{"/usr/local/bin/fish", "darwin", FormatFish},
+		{"/usr/bin/nu", "linux", FormatNushell},
+		{"/usr/bin/pwsh", "linux", FormatPowershell},
+		{`C:\Windows\System32\cmd.exe`, "windows", FormatCmd},
+		{"", "windows", FormatPowershell},
+		{"", "linux", FormatSh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell+"/"+tt.goos, func(t *testing.T) {
+			if got := detectFormat(tt.shell, tt.goos); got != tt.want {
+				t.Errorf("detectFormat(%q, %q) = %q, want %q", tt.shell, tt.goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCredentials(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatSh, `export AWS_SECRET_ACCESS_KEY='secret'\''with\quote'`},
+		{FormatFish, `set -gx AWS_SECRET_ACCESS_KEY 'secret\'with\\quote';`},
+		{FormatPowershell, `$Env:AWS_SECRET_ACCESS_KEY = 'secret''with\quote'`},
+		{FormatCmd, `set "AWS_SECRET_ACCESS_KEY=secret'with\quote"`},
+		{FormatNushell, `$env.AWS_SECRET_ACCESS_KEY = "secret'with\\quote"`},
+		{FormatDotenv, `AWS_SECRET_ACCESS_KEY=secret'with\quote`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := FormatCredentials(tt.format, testCredentials(), "dev", "eu-west-1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(out, tt.want+"\n") {
+				t.Errorf("output does not contain %q:\n%s", tt.want, out)
+			}
+			if !strings.Contains(out, "AWS_REGION") || !strings.Contains(out, "AWS_PROFILE") {
+				t.Errorf("output does not contain region and profile:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestFormatCredentials_Cmd(t *testing.T) {
+	creds := &aws.Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "100%secret%PATH%"}
+
+	out, err := FormatCredentials(FormatCmd, creds, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `set "AWS_SECRET_ACCESS_KEY=100%%secret%%PATH%%"`; !strings.Contains(out, want+"\n") {
+		t.Errorf("output does not contain %q:\n%s", want, out)
+	}
+
+	creds.SecretAccessKey = `secret"with&quote`
+	if _, err := FormatCredentials(FormatCmd, creds, "", ""); err == nil {
+		t.Error("expected an error for a value with a double quote")
+	}
+}
+
+func TestFormatCredentials_SkipsEmptyValues(t *testing.T) {
+	creds := &aws.Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret"}
+
+	out, err := FormatCredentials(FormatSh, creds, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"AWS_SESSION_TOKEN", "AWS_EXPIRES", "AWS_REGION", "AWS_PROFILE"} {
+		if strings.Contains(out, name) {
+			t.Errorf("output should not contain %s:\n%s", name, out)
+		}
+	}
+}
+
+func TestFormatCredentials_Json(t *testing.T) {
+	out, err := FormatCredentials(FormatJson, testCredentials(), "dev", "eu-west-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(out), &vars); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+
+	if vars["AWS_SECRET_ACCESS_KEY"] != testCredentials().SecretAccessKey {
+		t.Errorf("AWS_SECRET_ACCESS_KEY = %q", vars["AWS_SECRET_ACCESS_KEY"])
+	}
+	if vars["AWS_REGION"] != "eu-west-1" {
+		t.Errorf("AWS_REGION = %q, want eu-west-1", vars["AWS_REGION"])
+	}
+}
+
+func TestFormatCredentials_CredentialProcess(t *testing.T) {
+	out, err := FormatCredentials(FormatCredentialProcess, testCredentials(), "dev", "eu-west-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var creds awsCredsStdOut
+	if err := json.Unmarshal([]byte(out), &creds); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+
+	if creds.Version != 1 {
+		t.Errorf("Version = %d, want 1", creds.Version)
+	}
+	if creds.Expiration != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expiration = %q, want 2024-01-02T03:04:05Z", creds.Expiration)
+	}
+}
+
+func TestFormatCredentials_InvalidFormat(t *testing.T) {
+	if _, err := FormatCredentials("csh", testCredentials(), "", ""); err == nil {
+		t.Error("expected error for invalid format")
+	}
+}
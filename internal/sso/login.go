@@ -8,23 +8,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	agentclient "github.com/AndreZiviani/aws-fuzzy/internal/agent/client"
 	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
 	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
 	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/common-fate/clio"
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
-func NewLogin(profile, token string, ask, verbose, url, noCache bool) *Login {
+func NewLogin(profile, token, format string, ask, verbose, url, noCache, writeCredentials bool) *Login {
 	login := Login{
-		Profile: profile,
-		MFATOTP: token,
-		Ask:     ask,
-		Verbose: verbose,
-		Url:     url,
-		NoCache: noCache,
+		Profile:          profile,
+		MFATOTP:          token,
+		Format:           format,
+		Ask:              ask,
+		Verbose:          verbose,
+		Url:              url,
+		NoCache:          noCache,
+		WriteCredentials: writeCredentials,
 	}
 
 	return &login
@@ -50,14 +55,27 @@ func (p *Login) Execute(ctx context.Context) error {
 		clio.SetLevelFromString("debug")
 	}
 
+	if p.Format != "" && !IsValidFormat(p.Format) {
+		return fmt.Errorf("invalid format '%s', expected one of: %s", p.Format, strings.Join(CredentialsFormats, ", "))
+	}
+
 	creds, err := p.GetCredentials(ctx)
 	if err != nil {
 		return err
 	}
 
-	p.PrintCredentials(creds)
+	if p.WriteCredentials {
+		credsPath := config.DefaultSharedCredentialsFilename()
+		err = WriteCredentialsFile(credsPath, p.Profile, creds)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Credentials for profile %s written to %s, they will expire at %s", p.Profile, credsPath, creds.Expires.Local().Format(time.RFC1123))
+		return nil
+	}
 
-	return err
+	return p.PrintCredentials(ctx, creds)
 }
 
 func (p *Login) AskAuth() bool {
@@ -88,138 +106,66 @@ func (p *Login) GetCredentials(ctx context.Context) (*aws.Credentials, error) {
 		return nil, err
 	}
 
-	credstore := securestorage.NewSecureSSOTokenStorage()
+	credstore := securestorage.NewSecureRoleCredentialsStorage()
 
 	// Check cached role credentials (unless NoCache)
 	if p.NoCache {
-		_ = credstore.SecureStorage.Clear(profile.Name)
-	} else {
-		var creds aws.Credentials
-		if credstore.SecureStorage.Retrieve(profile.Name, &creds) == nil {
-			if creds.HasKeys() && !creds.Expired() {
-				return &creds, nil
-			}
-			_ = credstore.SecureStorage.Clear(profile.Name)
-		}
-
-		// Check parent profile cached credentials
-		if len(profile.Parents) > 0 {
-			var parentCreds aws.Credentials
-			if credstore.SecureStorage.Retrieve(profile.Parents[0].Name, &parentCreds) == nil {
-				creds, err := p.AssumeRoleWithCreds(ctx, &parentCreds)
-				if err == nil {
-					return &creds, nil
-				}
-				clio.Debugf("failed to use cached parent credentials: %s", err)
-			}
-		}
+		credstore.ClearCredentials(ctx, profile.Name)
+	} else if creds := credstore.GetValidCredentials(ctx, profile.Name); creds != nil {
+		return creds, nil
 	}
 
-	p.AskAuth()
-	p.checkExpiredCreds(ctx)
-
-	clio.Infof("Could not find cached credentials, refreshing...")
-
-	// Get fresh credentials — AssumeTerminal handles SSO token management internally
-	var creds aws.Credentials
-	if profile.ProfileType == awsprofile.ProfileTypeIAM && profile.AWSConfig.MFASerial != "" {
-		creds, err = p.LoginMFA(ctx)
-	} else {
-		creds, err = profile.AssumeTerminal(ctx, awsprofile.ConfigOpts{Duration: time.Hour, PrintOnly: p.Url})
-	}
+	creds, err := p.chainCredentials(ctx, profile)
 	if err != nil {
 		return nil, err
 	}
 
-	// cache role credentials
-	_ = credstore.SecureStorage.Store(profile.Name, creds)
-
 	return &creds, nil
 }
 
-func (p *Login) AssumeRoleWithCreds(ctx context.Context, parentcreds *aws.Credentials) (aws.Credentials, error) {
-	profile, err := p.GetProfile(p.Profile)
+// GetCredentialsValidFor returns credentials that are valid for at least d,
+// the cached credentials are refreshed if they expire sooner than that
+func (p *Login) GetCredentialsValidFor(ctx context.Context, d time.Duration) (*aws.Credentials, error) {
+	creds, err := p.GetCredentials(ctx)
 	if err != nil {
-		return aws.Credentials{}, err
+		return nil, err
 	}
 
-	cfg, _ := NewAwsConfig(ctx, parentcreds)
-
-	stsClient := sts.NewFromConfig(cfg)
-
-	session, err := stsClient.AssumeRole(ctx,
-		&sts.AssumeRoleInput{
-			RoleArn:         &profile.AWSConfig.RoleARN,
-			RoleSessionName: &profile.Name,
-		})
-
-	if err != nil {
-		return aws.Credentials{}, err
+	if !creds.CanExpire || time.Now().Add(d).Before(creds.Expires) {
+		return creds, nil
 	}
 
-	creds := awsprofile.TypeCredsToAwsCreds(*session.Credentials)
-	credstore := securestorage.NewSecureSSOTokenStorage()
-	_ = credstore.SecureStorage.Store(profile.Name, creds)
-
-	return creds, err
+	noCache := p.NoCache
+	p.NoCache = true
+	defer func() { p.NoCache = noCache }()
 
+	return p.GetCredentials(ctx)
 }
-func (p *Login) LoginMFA(ctx context.Context) (aws.Credentials, error) {
-	// Granted/AWS CLI only implements MFA authentication when assuming a role
-	// Here we want to just login and get a session token for the user without assuming a role
 
-	p.LoadProfiles()
-	profile, err := p.GetProfile(p.Profile)
-	if err != nil {
-		return aws.Credentials{}, err
+// GetCredentialsViaAgent returns credentials from the aws-fuzzy agent if it is running,
+// otherwise falls back to GetCredentials
+func (p *Login) GetCredentialsViaAgent(ctx context.Context) (*aws.Credentials, error) {
+	if p.NoCache {
+		return p.GetCredentials(ctx)
 	}
 
-	var creds aws.Credentials
-
-	if len(profile.AWSConfig.SourceProfileName) > 0 {
-		// check if we have cached credentials for source profile
-		credstore := securestorage.NewSecureSSOTokenStorage()
-		_ = credstore.SecureStorage.Retrieve(profile.AWSConfig.SourceProfileName, &creds)
+	res, err := agentclient.GetCredentials(ctx, p.Profile)
+	if err != nil {
+		clio.Debugf("not using aws-fuzzy agent: %s", err)
+		return p.GetCredentials(ctx)
 	}
 
-	cfg, _ := NewAwsConfig(ctx, &profile.AWSConfig.Credentials)
-	stsClient := sts.NewFromConfig(cfg)
+	clio.Debugf("using credentials from aws-fuzzy agent")
 
-	if len(creds.AccessKeyID) == 0 {
-		var mfatotp string
-		if len(p.MFATOTP) == 0 {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Fprintf(os.Stderr, "MFA Token: ")
-			mfatotp, _ = reader.ReadString('\n')
-			mfatotp = strings.TrimSuffix(mfatotp, "\n")
-		} else {
-			mfatotp = p.MFATOTP
+	if !res.SessionExpiresAt.IsZero() {
+		cfg, _ := afconfig.NewLoadedConfig()
+		_, warnBefore := cfg.AgentSession()
+		if time.Until(res.SessionExpiresAt) < warnBefore {
+			clio.Warnf("Your IAM Identity Center portal session expires at %s, login again to avoid interruptions", res.SessionExpiresAt.Local().Format(time.Kitchen))
 		}
-
-		params := sts.GetSessionTokenInput{
-			SerialNumber: &profile.AWSConfig.MFASerial,
-			TokenCode:    &mfatotp,
-		}
-		session, err := stsClient.GetSessionToken(ctx, &params)
-		if err != nil {
-			return aws.Credentials{}, err
-		}
-
-		creds = awsprofile.TypeCredsToAwsCreds(*session.Credentials)
-	} else {
-		params := sts.AssumeRoleInput{
-			RoleArn:         &profile.AWSConfig.RoleARN,
-			RoleSessionName: &profile.Name,
-		}
-		session, err := stsClient.AssumeRole(ctx, &params)
-		if err != nil {
-			return aws.Credentials{}, err
-		}
-
-		creds = awsprofile.TypeCredsToAwsCreds(*session.Credentials)
 	}
 
-	return creds, err
+	return &res.Credentials, nil
 }
 
 func (p *Login) checkExpiredCreds(ctx context.Context) {
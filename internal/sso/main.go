@@ -1,28 +1,33 @@
 package sso
 
 import (
+	"bufio"
+
 	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
 	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
 	"github.com/urfave/cli/v2"
 )
 
 type Login struct {
-	Profile  string
-	Ask      bool
-	MFATOTP  string
-	Verbose  bool
-	Url      bool
-	NoCache  bool
-	profiles awsprofile.Profiles
+	Profile          string
+	Ask              bool
+	MFATOTP          string
+	Format           string
+	Verbose          bool
+	Url              bool
+	NoCache          bool
+	WriteCredentials bool
+	profiles         awsprofile.Profiles
 }
 
 type Console struct {
-	Profile string
-	Region  string
-	Service string
-	Url     bool
-	Verbose bool
-	NoCache bool
+	Profile  string
+	Region   string
+	Service  string
+	Resource string
+	Url      bool
+	Verbose  bool
+	NoCache  bool
 }
 
 type Browser struct {
@@ -32,6 +37,25 @@ type Browser struct {
 }
 
 type Configure struct {
+	StartURL       string
+	Region         string
+	SessionName    string
+	RolePreference []string
+	NameTemplate   string
+	AllRoles       bool
+	Yes            bool
+	Prune          bool
+	Verbose        bool
+	reader         *bufio.Reader
+}
+
+type Sessions struct {
+	Session string
+	Verbose bool
+}
+
+type Mfa struct {
+	Profile string
 	Verbose bool
 }
 
@@ -56,14 +80,18 @@ func Command() *cli.Command {
 					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
 					&cli.BoolFlag{Name: "url", Aliases: []string{"u"}, Usage: "Only print login url"},
 					&cli.BoolFlag{Name: "no-cache", Aliases: []string{"n"}, Usage: "Dont use cached credentials"},
+					&cli.BoolFlag{Name: "write-credentials", Aliases: []string{"w"}, Usage: "Write credentials to the shared credentials file instead of printing them"},
+					&cli.StringFlag{Name: "format", Aliases: []string{"f"}, Usage: "Output format: sh, fish, powershell, cmd, nushell, json, dotenv or credential-process (default: detected from $SHELL)", EnvVars: []string{"AWSFUZZY_FORMAT"}},
 				},
 				Action: func(c *cli.Context) error {
 					login := NewLogin(c.String("profile"),
 						c.String("token"),
+						c.String("format"),
 						c.Bool("ask"),
 						c.Bool("verbose"),
 						c.Bool("url"),
 						c.Bool("no-cache"),
+						c.Bool("write-credentials"),
 					)
 
 					return login.Execute(c.Context)
@@ -76,6 +104,7 @@ func Command() *cli.Command {
 					&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
 					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "What AWS region to use", Value: "us-east-1", EnvVars: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
 					&cli.StringFlag{Name: "service", Aliases: []string{"s"}, Usage: "Open console at specific service"},
+					&cli.StringFlag{Name: "resource", Usage: "Open console at the page of a resource ARN: EC2 instance, S3 bucket, Lambda function, CloudWatch log group or RDS cluster"},
 					&cli.BoolFlag{Name: "url", Aliases: []string{"u"}, Usage: "Only print login url"},
 					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
 					&cli.BoolFlag{Name: "no-cache", Aliases: []string{"n"}, Usage: "Dont use cached credentials"},
@@ -84,6 +113,7 @@ func Command() *cli.Command {
 					console := NewConsole(c.String("profile"),
 						c.String("region"),
 						c.String("service"),
+						c.String("resource"),
 						c.Bool("url"),
 						c.Bool("verbose"),
 						c.Bool("no-cache"),
@@ -113,14 +143,101 @@ func Command() *cli.Command {
 				Name:  "configure",
 				Usage: "Configure AWS SSO",
 				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "start-url", Aliases: []string{"u"}, Usage: "SSO start url", EnvVars: []string{"AWSFUZZY_SSO_START_URL"}},
+					&cli.StringFlag{Name: "region", Aliases: []string{"r"}, Usage: "SSO region", EnvVars: []string{"AWSFUZZY_SSO_REGION"}},
+					&cli.StringFlag{Name: "session-name", Aliases: []string{"s"}, Usage: "Name of the sso-session section, defaults to the subdomain of the start url"},
+					&cli.StringSliceFlag{Name: "role-preference", Usage: "Roles to use when an account has more than one, in order of preference, e.g. 'AdministratorAccess,ReadOnlyAccess'"},
+					&cli.StringFlag{Name: "name-template", Aliases: []string{"t"}, Usage: "Template of the profile names, available fields: .AccountName, .AccountID, .Email and .Role (default: \"" + DefaultNameTemplate + "\" or \"" + DefaultAllRolesNameTemplate + "\" with --all-roles)"},
+					&cli.BoolFlag{Name: "all-roles", Aliases: []string{"a"}, Usage: "Create one profile per account and role instead of choosing one role per account"},
+					&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Do not prompt, use the role preference and name template"},
+					&cli.BoolFlag{Name: "prune", Usage: "Remove profiles of accounts and roles that are no longer accessible"},
 					&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
 				},
 				Action: func(c *cli.Context) error {
-					login := NewConfigure(c.Bool("verbose"))
+					login := NewConfigure(c.String("start-url"),
+						c.String("region"),
+						c.String("session-name"),
+						c.StringSlice("role-preference"),
+						c.String("name-template"),
+						c.Bool("all-roles"),
+						c.Bool("yes"),
+						c.Bool("prune"),
+						c.Bool("verbose"),
+					)
 
 					return login.Execute(c.Context)
 				},
 			},
+			{
+				Name:  "sessions",
+				Usage: "Manage the IAM Identity Center sessions and their cached tokens",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "list",
+						Usage:     "List the sessions with the expiry of their tokens",
+						Flags:     []cli.Flag{&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"}},
+						ArgsUsage: " ",
+						Action: func(c *cli.Context) error {
+							return NewSessions("", c.Bool("verbose")).List(c.Context)
+						},
+					},
+					{
+						Name:      "status",
+						Usage:     "Show the token, portal session and client registrations of every session or of a single one",
+						Flags:     []cli.Flag{&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"}},
+						ArgsUsage: "[session]",
+						Action: func(c *cli.Context) error {
+							return NewSessions(c.Args().First(), c.Bool("verbose")).Status(c.Context)
+						},
+					},
+					{
+						Name:      "login",
+						Usage:     "Login to a session, without assuming a role",
+						Flags:     []cli.Flag{&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"}},
+						ArgsUsage: "<session>",
+						Action: func(c *cli.Context) error {
+							return NewSessions(c.Args().First(), c.Bool("verbose")).Login(c.Context)
+						},
+					},
+					{
+						Name:      "logout",
+						Usage:     "Logout from a session and clear its cached tokens, client registrations and role credentials",
+						Flags:     []cli.Flag{&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"}},
+						ArgsUsage: "<session>",
+						Action: func(c *cli.Context) error {
+							return NewSessions(c.Args().First(), c.Bool("verbose")).Logout(c.Context)
+						},
+					},
+				},
+			},
+			{
+				Name:  "mfa",
+				Usage: "Manage the TOTP secret used to compute the MFA codes of a profile",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "set-secret",
+						Usage: "Store the TOTP secret of the MFA device of a profile in the keyring, it is read from stdin when it is not a terminal",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+							&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+						},
+						Action: func(c *cli.Context) error {
+							return NewMfa(c.String("profile"), c.Bool("verbose")).SetSecret(c.Context)
+						},
+					},
+					{
+						Name:  "remove-secret",
+						Usage: "Remove the TOTP secret of the MFA device of a profile from the keyring",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "What profile to use", Value: "$AWS_PROFILE", EnvVars: []string{"AWSFUZZY_PROFILE", "AWS_PROFILE"}},
+							&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "Enable verbose messages"},
+						},
+						Action: func(c *cli.Context) error {
+							return NewMfa(c.String("profile"), c.Bool("verbose")).RemoveSecret(c.Context)
+						},
+					},
+				},
+			},
 			{
 				Name:  "credential-process",
 				Usage: "Integrate with native AWS CLI",
@@ -0,0 +1,124 @@
+package sso
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/common-fate/clio"
+	opentracing "github.com/opentracing/opentracing-go"
+	"golang.org/x/term"
+)
+
+func NewMfa(profile string, verbose bool) *Mfa {
+	mfa := Mfa{
+		Profile: profile,
+		Verbose: verbose,
+	}
+
+	return &mfa
+}
+
+// execute runs fn with the MFA device of the profile, the first mfa_serial of its source_profile chain
+func (p *Mfa) execute(ctx context.Context, operation string, fn func(context.Context, string) error) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing, %s", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, operation)
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	profile, err := profiles.LoadInitialisedProfile(ctx, p.Profile)
+	if err != nil {
+		return err
+	}
+
+	serial := mfaSerial(append(append([]*awsprofile.Profile{}, profile.Parents...), profile))
+	if serial == "" {
+		return fmt.Errorf("profile %s does not use an MFA device, set mfa_serial in the profile or in its source profile", p.Profile)
+	}
+
+	return fn(ctx, serial)
+}
+
+// SetSecret stores the TOTP secret of the MFA device of the profile, the MFA codes are computed from it instead of asked
+func (p *Mfa) SetSecret(ctx context.Context) error {
+	return p.execute(ctx, "ssomfasetsecret", func(ctx context.Context, serial string) error {
+		secret, err := readTOTPSecret(serial)
+		if err != nil {
+			return err
+		}
+
+		code, err := awsprofile.TOTP(secret, time.Now())
+		if err != nil {
+			return err
+		}
+
+		store := securestorage.NewSecureTOTPSecretStorage()
+		if err := store.StoreSecret(ctx, serial, secret); err != nil {
+			return err
+		}
+
+		clio.Successf("TOTP secret of %s stored, the current code is %s", serial, code)
+		return nil
+	})
+}
+
+// RemoveSecret removes the TOTP secret of the MFA device of the profile
+func (p *Mfa) RemoveSecret(ctx context.Context) error {
+	return p.execute(ctx, "ssomfaremovesecret", func(ctx context.Context, serial string) error {
+		store := securestorage.NewSecureTOTPSecretStorage()
+		if err := store.ClearSecret(ctx, serial); err != nil {
+			return err
+		}
+
+		clio.Successf("TOTP secret of %s removed", serial)
+		return nil
+	})
+}
+
+// readTOTPSecret asks for the secret or reads it from stdin when it is not a terminal
+func readTOTPSecret(serial string) (string, error) {
+	var secret string
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		in := survey.Password{Message: fmt.Sprintf("TOTP secret of %s", serial)}
+		withStdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+		if err := survey.AskOne(&in, &secret, withStdio); err != nil {
+			return "", err
+		}
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the TOTP secret from stdin, %w", err)
+		}
+		secret = line
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", errors.New("empty TOTP secret")
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,94 @@
+package sso
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	gconsole "github.com/common-fate/granted/pkg/console"
+)
+
+// EC2InstanceArn returns the ARN of an EC2 instance
+func EC2InstanceArn(region, accountID, instanceID string) string {
+	return arn.ARN{
+		Partition: gconsole.GetPartitionFromRegion(region).String(),
+		Service:   "ec2",
+		Region:    region,
+		AccountID: accountID,
+		Resource:  "instance/" + instanceID,
+	}.String()
+}
+
+// consoleHost returns the console url prefix of the partition, e.g. https://eu-west-1.console.aws.amazon.com/
+func consoleHost(partition, region string) string {
+	switch partition {
+	case "aws-us-gov":
+		return gconsole.Gov.RegionalConsoleHostString(region)
+	case "aws-cn":
+		return gconsole.Cn.RegionalConsoleHostString(region)
+	}
+
+	return gconsole.Default.RegionalConsoleHostString(region)
+}
+
+// ResourceURL maps the ARN of a resource to its page in the console, region is used for global resources like S3 buckets.
+// The region of the console session is returned with the url
+func ResourceURL(resourceArn, region string) (string, string, error) {
+	a, err := arn.Parse(resourceArn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid resource ARN %s, %w", resourceArn, err)
+	}
+
+	if a.Region != "" {
+		region = a.Region
+	}
+
+	host := consoleHost(a.Partition, region)
+	query := "?region=" + region
+
+	switch a.Service {
+	case "ec2":
+		id, ok := strings.CutPrefix(a.Resource, "instance/")
+		if !ok {
+			break
+		}
+		return region, host + "ec2/home" + query + "#InstanceDetails:instanceId=" + id, nil
+
+	case "s3":
+		bucket, prefix, _ := strings.Cut(a.Resource, "/")
+		u := consoleHost(a.Partition, "") + "s3/buckets/" + bucket + query
+		if prefix != "" {
+			u += "&prefix=" + url.QueryEscape(prefix)
+		}
+		return region, u, nil
+
+	case "lambda":
+		name, ok := strings.CutPrefix(a.Resource, "function:")
+		if !ok {
+			break
+		}
+		// drop the version or alias
+		name, _, _ = strings.Cut(name, ":")
+		return region, host + "lambda/home" + query + "#/functions/" + name, nil
+
+	case "logs":
+		name, ok := strings.CutPrefix(a.Resource, "log-group:")
+		if !ok {
+			break
+		}
+		name = strings.TrimSuffix(name, ":*")
+		// the console expects the name url encoded twice with '%' replaced by '$'
+		name = strings.ReplaceAll(url.QueryEscape(url.QueryEscape(name)), "%", "$")
+		return region, host + "cloudwatch/home" + query + "#logsV2:log-groups/log-group/" + name, nil
+
+	case "rds":
+		kind, name, ok := strings.Cut(a.Resource, ":")
+		if !ok || (kind != "cluster" && kind != "db") {
+			break
+		}
+		return region, fmt.Sprintf("%srds/home%s#database:id=%s;is-cluster=%t", host, query, name, kind == "cluster"), nil
+	}
+
+	return "", "", fmt.Errorf("opening %s resources in the console is not supported, supported resources are EC2 instances, S3 buckets, Lambda functions, CloudWatch log groups and RDS clusters and instances", a.Service)
+}
@@ -0,0 +1,107 @@
+package sso
+
+import (
+	"testing"
+)
+
+func TestResourceURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		arn        string
+		region     string
+		wantRegion string
+		wantURL    string
+	}{
+		{
+			"ec2 instance",
+			"arn:aws:ec2:eu-west-1:123456789012:instance/i-0abc",
+			"us-east-1",
+			"eu-west-1",
+			"https://eu-west-1.console.aws.amazon.com/ec2/home?region=eu-west-1#InstanceDetails:instanceId=i-0abc",
+		},
+		{
+			"s3 bucket uses the given region",
+			"arn:aws:s3:::my-bucket",
+			"sa-east-1",
+			"sa-east-1",
+			"https://console.aws.amazon.com/s3/buckets/my-bucket?region=sa-east-1",
+		},
+		{
+			"s3 prefix",
+			"arn:aws:s3:::my-bucket/logs/2024",
+			"us-east-1",
+			"us-east-1",
+			"https://console.aws.amazon.com/s3/buckets/my-bucket?region=us-east-1&prefix=logs%2F2024",
+		},
+		{
+			"lambda function alias",
+			"arn:aws:lambda:us-east-2:123456789012:function:my-function:live",
+			"us-east-1",
+			"us-east-2",
+			"https://us-east-2.console.aws.amazon.com/lambda/home?region=us-east-2#/functions/my-function",
+		},
+		{
+			"log group",
+			"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/my-function:*",
+			"us-east-1",
+			"us-east-1",
+			"https://console.aws.amazon.com/cloudwatch/home?region=us-east-1#logsV2:log-groups/log-group/$252Faws$252Flambda$252Fmy-function",
+		},
+		{
+			"rds cluster",
+			"arn:aws:rds:eu-central-1:123456789012:cluster:my-cluster",
+			"us-east-1",
+			"eu-central-1",
+			"https://eu-central-1.console.aws.amazon.com/rds/home?region=eu-central-1#database:id=my-cluster;is-cluster=true",
+		},
+		{
+			"govcloud partition",
+			"arn:aws-us-gov:ec2:us-gov-east-1:123456789012:instance/i-0abc",
+			"us-gov-west-1",
+			"us-gov-east-1",
+			"https://us-gov-east-1.console.amazonaws-us-gov.com/ec2/home?region=us-gov-east-1#InstanceDetails:instanceId=i-0abc",
+		},
+		{
+			"china partition",
+			"arn:aws-cn:lambda:cn-northwest-1:123456789012:function:my-function",
+			"cn-north-1",
+			"cn-northwest-1",
+			"https://cn-northwest-1.console.amazonaws.cn/lambda/home?region=cn-northwest-1#/functions/my-function",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, url, err := ResourceURL(tt.arn, tt.region)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if region != tt.wantRegion {
+				t.Errorf("region = %s, want %s", region, tt.wantRegion)
+			}
+			if url != tt.wantURL {
+				t.Errorf("url = %s\nwant  %s", url, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestResourceURLUnsupported(t *testing.T) {
+	for _, arn := range []string{
+		"not an arn",
+		"arn:aws:sqs:us-east-1:123456789012:my-queue",
+		"arn:aws:ec2:us-east-1:123456789012:volume/vol-0abc",
+	} {
+		if _, _, err := ResourceURL(arn, "us-east-1"); err == nil {
+			t.Errorf("expected an error for %s", arn)
+		}
+	}
+}
+
+func TestEC2InstanceArn(t *testing.T) {
+	got := EC2InstanceArn("us-gov-west-1", "123456789012", "i-0abc")
+	want := "arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-0abc"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
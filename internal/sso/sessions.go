@@ -0,0 +1,241 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AndreZiviani/aws-fuzzy/internal/afconfig"
+	"github.com/AndreZiviani/aws-fuzzy/internal/awsprofile"
+	"github.com/AndreZiviani/aws-fuzzy/internal/securestorage"
+	"github.com/AndreZiviani/aws-fuzzy/internal/tracing"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/common-fate/clio"
+	"github.com/hako/durafmt"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func NewSessions(session string, verbose bool) *Sessions {
+	sessions := Sessions{
+		Session: session,
+		Verbose: verbose,
+	}
+
+	return &sessions
+}
+
+// execute runs fn with the IAM Identity Center instances of the AWS config file,
+// only the selected one if a session was given
+func (p *Sessions) execute(ctx context.Context, operation string, fn func(context.Context, []awsprofile.SSOInstance) error) error {
+	closer, err := tracing.InitTracing()
+	if err != nil {
+		fmt.Printf("failed to initialize tracing, %s\n", err)
+	}
+	defer func() { _ = closer.Close() }()
+
+	tracer := opentracing.GlobalTracer()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, operation)
+	defer span.Finish()
+
+	if p.Verbose {
+		clio.SetLevelFromString("debug")
+	}
+
+	profiles, err := awsprofile.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	if p.Session == "" {
+		return fn(ctx, profiles.SSOInstances(ctx))
+	}
+
+	instance, err := profiles.SSOInstance(ctx, p.Session)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, p.Session)
+	}
+
+	return fn(ctx, []awsprofile.SSOInstance{*instance})
+}
+
+func (p *Sessions) List(ctx context.Context) error {
+	return p.execute(ctx, "ssosessionslist", func(ctx context.Context, instances []awsprofile.SSOInstance) error {
+		tokenStore := securestorage.NewSecureSSOTokenStorage()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SESSION\tSTART URL\tREGION\tPROFILES\tTOKEN EXPIRES\tREFRESH TOKEN\tREGISTRATION EXPIRES")
+
+		for _, instance := range instances {
+			expires, refresh, registration := "-", "-", "-"
+
+			token, err := tokenStore.GetSSOToken(ctx, instance.Key)
+			if err == nil {
+				expires = describeExpiry(token.Expiry)
+				refresh = "no"
+				if token.RefreshToken != nil && *token.RefreshToken != "" {
+					refresh = "yes"
+				}
+				if !token.RegistrationExpiresAt.IsZero() {
+					registration = describeExpiry(token.RegistrationExpiresAt)
+				}
+			} else if !errors.Is(err, securestorage.ErrNotFound) {
+				clio.Debugf("failed to read the token of %s: %s", instance.Key, err)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", instance.Key, instance.StartURL, instance.Region, len(instance.Profiles), expires, refresh, registration)
+		}
+
+		return w.Flush()
+	})
+}
+
+func (p *Sessions) Status(ctx context.Context) error {
+	return p.execute(ctx, "ssosessionsstatus", func(ctx context.Context, instances []awsprofile.SSOInstance) error {
+		afcfg, err := afconfig.NewLoadedConfig()
+		if err != nil {
+			return err
+		}
+		sessionDuration, _ := afcfg.AgentSession()
+
+		tokenStore := securestorage.NewSecureSSOTokenStorage()
+		regStore := securestorage.NewSecureClientRegistrationStorage()
+
+		for i, instance := range instances {
+			if i > 0 {
+				fmt.Println()
+			}
+
+			fmt.Printf("%s\n", instance.Key)
+			fmt.Printf("  start url:  %s\n", instance.StartURL)
+			fmt.Printf("  region:     %s\n", instance.Region)
+			fmt.Printf("  profiles:   %s\n", strings.Join(instance.Profiles, ", "))
+
+			token, err := tokenStore.GetSSOToken(ctx, instance.Key)
+			if err != nil {
+				fmt.Printf("  token:      not logged in\n")
+			} else {
+				printToken(os.Stdout, token, sessionDuration)
+			}
+
+			for _, key := range awsprofile.ClientRegistrationKeys(instance.StartURL) {
+				flow := "device code"
+				if key != instance.StartURL {
+					flow = "PKCE"
+				}
+
+				reg, err := regStore.GetRegistration(ctx, key)
+				if err != nil {
+					fmt.Printf("  client registration (%s): none\n", flow)
+					continue
+				}
+
+				fmt.Printf("  client registration (%s): expires %s\n", flow, describeExpiry(reg.RegistrationExpiresAt))
+			}
+		}
+
+		return nil
+	})
+}
+
+func printToken(w io.Writer, token *securestorage.SSOToken, sessionDuration time.Duration) {
+	fmt.Fprintf(w, "  token:      expires %s\n", describeExpiry(token.Expiry))
+
+	if token.RefreshToken != nil && *token.RefreshToken != "" {
+		fmt.Fprintf(w, "  refresh:    yes, the token is refreshed without a new login\n")
+	} else {
+		fmt.Fprintf(w, "  refresh:    no, a new login is required when the token expires\n")
+	}
+
+	if !token.IssuedAt.IsZero() {
+		// refreshing the token does not extend the portal session
+		fmt.Fprintf(w, "  session:    started %s, expires %s\n", token.IssuedAt.Local().Format(time.RFC1123), describeExpiry(token.IssuedAt.Add(sessionDuration)))
+	}
+}
+
+func (p *Sessions) Login(ctx context.Context) error {
+	if p.Session == "" {
+		return fmt.Errorf("missing session name, run 'aws-fuzzy sso sessions list' to see the available sessions")
+	}
+
+	return p.execute(ctx, "ssosessionslogin", func(ctx context.Context, instances []awsprofile.SSOInstance) error {
+		instance := instances[0]
+		tokenStore := securestorage.NewSecureSSOTokenStorage()
+
+		token := tokenStore.GetValidSSOToken(ctx, instance.Key)
+		if token != nil {
+			clio.Successf("Already logged in to %s, the token expires %s", instance.Key, describeExpiry(token.Expiry))
+			return nil
+		}
+
+		cfg, err := NewAwsConfig(ctx, nil, config.WithRegion(instance.Region))
+		if err != nil {
+			return err
+		}
+
+		token, err = awsprofile.SSOLoginFlow(ctx, cfg, instance.StartURL, instance.Key, false)
+		if err != nil {
+			return err
+		}
+		tokenStore.StoreSSOToken(ctx, instance.Key, *token)
+
+		clio.Successf("Logged in to %s, the token expires %s", instance.Key, describeExpiry(token.Expiry))
+
+		return nil
+	})
+}
+
+func (p *Sessions) Logout(ctx context.Context) error {
+	if p.Session == "" {
+		return fmt.Errorf("missing session name, run 'aws-fuzzy sso sessions list' to see the available sessions")
+	}
+
+	return p.execute(ctx, "ssosessionslogout", func(ctx context.Context, instances []awsprofile.SSOInstance) error {
+		instance := instances[0]
+		tokenStore := securestorage.NewSecureSSOTokenStorage()
+
+		token, err := tokenStore.GetSSOToken(ctx, instance.Key)
+		if err == nil && token.Expiry.After(time.Now()) {
+			cfg, err := NewAwsConfig(ctx, nil, config.WithRegion(instance.Region))
+			if err != nil {
+				return err
+			}
+
+			// invalidates the portal session, the token and the role credentials it issued
+			_, err = sso.NewFromConfig(cfg).Logout(ctx, &sso.LogoutInput{AccessToken: &token.AccessToken})
+			if err != nil {
+				clio.Warnf("failed to logout from %s, clearing the local cache anyway: %s", instance.Key, err)
+			}
+		}
+
+		tokenStore.ClearSSOToken(ctx, instance.Key)
+
+		regStore := securestorage.NewSecureClientRegistrationStorage()
+		for _, key := range awsprofile.ClientRegistrationKeys(instance.StartURL) {
+			regStore.ClearRegistration(ctx, key)
+		}
+
+		credStore := securestorage.NewSecureRoleCredentialsStorage()
+		for _, profile := range instance.Profiles {
+			credStore.ClearCredentials(ctx, profile)
+		}
+
+		clio.Successf("Logged out from %s", instance.Key)
+
+		return nil
+	})
+}
+
+func describeExpiry(t time.Time) string {
+	d := time.Until(t)
+	if d <= 0 {
+		return fmt.Sprintf("%s (expired)", t.Local().Format(time.RFC1123))
+	}
+
+	return fmt.Sprintf("%s (in %s)", t.Local().Format(time.RFC1123), durafmt.Parse(d).LimitFirstN(2).String())
+}